@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"p2pbot/internal/app"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"p2pbot/internal/notify"
+	"p2pbot/internal/rabbitmq"
+	"p2pbot/internal/rediscl"
+	"p2pbot/internal/services"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Delivers notifications to discord, slack, linked telegram chats, user's webhooks and emails
+func main() {
+	// wait until all services are up
+	time.Sleep(10 * time.Second)
+	DB, cfg, err := app.Init()
+	if err != nil {
+		panic(err)
+	}
+
+	channelRepo := repository.NewChannelRepository(DB)
+	channelService := services.NewChannelService(channelRepo)
+	notificationRepo := repository.NewNotificationRepository(DB)
+	notificationService := services.NewNotificationService(notificationRepo)
+	webhookRepo := repository.NewWebhookRepository(DB)
+	webhookService := services.NewWebhookService(webhookRepo)
+	userRepo := repository.NewUserRepository(DB)
+	userService := services.NewUserService(userRepo)
+
+	rediscl.InitRedisClient(cfg.Redis.Host, cfg.Redis.Port)
+
+	api, err := tgbotapi.NewBotAPI(cfg.Telegram.APIkey)
+	if err != nil {
+		log.Fatal("Error connecting to telegram: ", err)
+	}
+	notifier := notify.NewNotifier(channelService, notificationService, map[string]notify.Sender{
+		models.ChannelTelegram: notify.NewTelegramSender(api),
+		models.ChannelDiscord:  notify.NewDiscordSender(),
+		models.ChannelSlack:    notify.NewSlackSender(),
+	})
+
+	// One connection serves every consumer, each consumer type has own queue
+	rabbit, err := rabbitmq.NewRabbitMQ(cfg)
+	if err != nil {
+		log.Fatal("Error starting rabbitmq: ", err)
+	}
+	defer rabbit.Close()
+	if err := rabbit.DeclareTopicExchange(services.NotifyExchange); err != nil {
+		log.Fatal("Error declaring exchange: ", err)
+	}
+	consumers := map[string]func(amqp.Delivery) error{
+		rabbitmq.QueueChannels: notifier.HandleNotification,
+		// Outgoing webhooks
+		rabbitmq.QueueWebhooks: notify.NewWebhookDispatcher(webhookService).HandleNotification,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Emails to users without telegram, batched to digests
+	if cfg.Email.Host != "" {
+		emailNotifier := notify.NewEmailNotifier(cfg, userService, notificationService)
+		consumers[rabbitmq.QueueEmail] = emailNotifier.HandleNotification
+		go emailNotifier.Start(ctx)
+	} else {
+		log.Println("SMTP host not configured, email notifications disabled")
+	}
+
+	for queue, handler := range consumers {
+		if err := rabbit.DeclareQueue(services.NotifyExchange, queue, services.NotificationBindings...); err != nil {
+			log.Fatal("Error declaring queue: ", err)
+		}
+		if err := rabbit.StartConsuming(queue, handler); err != nil {
+			log.Fatal("Error consuming notifications: ", err)
+		}
+	}
+	<-ctx.Done()
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"p2pbot/internal/app"
 	"p2pbot/internal/db/repository"
 	"p2pbot/internal/rabbitmq"
@@ -21,16 +22,25 @@ func main() {
 		panic(err)
 	}
 
+	exs, err := services.NewExchanges(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	trackerRepo := repository.NewTrackerRepository(DB)
 	userRepo := repository.NewUserRepository(DB)
-	trackerService := services.NewTrackerService(trackerRepo)
+	trackerService := services.NewTrackerService(trackerRepo, exs)
 	userService := services.NewUserService(userRepo)
 	subscriptionRepo := repository.NewSubscriptionRepository(DB)
 	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
-
-	bybit := services.NewBybitExcahnge(cfg)
-	binance := services.NewBinanceExchange(cfg)
-	exs := []services.ExchangeI{binance, bybit}
+	marketRepo := repository.NewMarketRepository(DB)
+	marketService := services.NewMarketService(marketRepo)
+	arbitrageRepo := repository.NewArbitrageRepository(DB)
+	arbitrageService := services.NewArbitrageService(arbitrageRepo, exs, cfg)
+	notificationRepo := repository.NewNotificationRepository(DB)
+	notificationService := services.NewNotificationService(notificationRepo)
+	settingsRepo := repository.NewSettingsRepository(DB)
+	settingsService := services.NewSettingsService(settingsRepo)
 
 	rabbit, err := rabbitmq.NewRabbitMQ(cfg)
 	if err != nil {
@@ -39,8 +49,18 @@ func main() {
 
 	rediscl.InitRedisClient(cfg.Redis.Host, cfg.Redis.Port)
 
-	observer := tasks.NewAdsObserver(trackerService, userService, subscriptionService, exs, rabbit)
+	observer := tasks.NewAdsObserver(trackerService, userService, subscriptionService, marketService, arbitrageService, notificationService, settingsService, exs.List(), rabbit,
+		tasks.NewScheduler(cfg))
+
+	// Bot sends commands, like immediate tracker check, through separate exchange
+	commands, err := rabbitmq.NewRabbitMQ(cfg)
+	if err != nil {
+		log.Fatal("Error starting rabbitmq: ", err)
+	}
+	if err := observer.ConsumeCommands(commands); err != nil {
+		log.Fatal("Error consuming commands: ", err)
+	}
 
 	ctx := context.Background()
-	observer.Start(1*time.Minute, ctx)
+	observer.Start(ctx)
 }
@@ -23,20 +23,34 @@ func main() {
 	//url := "https://p2p.binance.com/bapi/c2c/v2/friendly/c2c/adv/search"
 	//payload := `{"fiat":"CZK","page":1,"rows":10,"tradeType":"BUY","asset":"USDT","countries":[],"proMerchantAds":false,"shieldMerchantAds":false,"filterType":"all","periods":[],"additionalKycVerifyFilter":0,"publisherType":null,"payTypes":[],"classifies":["mass","profession"]}`
 
+	//Supported exchanges
+	exs, err := services.NewExchanges(cfg)
+	if err != nil {
+		log.Fatal("Error creating exchanges: ", err)
+	}
+
 	trackerRepo := repository.NewTrackerRepository(DB)
 	userRepo := repository.NewUserRepository(DB)
 
-	trackerService := services.NewTrackerService(trackerRepo)
+	trackerService := services.NewTrackerService(trackerRepo, exs)
 	userService := services.NewUserService(userRepo)
+	notificationRepo := repository.NewNotificationRepository(DB)
+	notificationService := services.NewNotificationService(notificationRepo)
+	channelRepo := repository.NewChannelRepository(DB)
+	channelService := services.NewChannelService(channelRepo)
 
 	rediscl.InitRedisClient(cfg.Redis.Host, cfg.Redis.Port)
 
-	//Supported exchanges
-	binance := services.NewBinanceExchange(cfg)
-	bybit := services.NewBybitExcahnge(cfg)
-	exs := []services.ExchangeI{binance, bybit}
+	// Commands to observer, like immediate tracker check
+	commands, err := rabbitmq.NewRabbitMQ(cfg)
+	if err != nil {
+		log.Fatal("Error starting rabbitmq: ", err)
+	}
+	if err := commands.DeclareExchange("commands"); err != nil {
+		log.Fatal("Error declaring exchange: ", err)
+	}
 
-	tgbot, err := bot.NewBot(cfg, userService, trackerService, exs)
+	tgbot, err := bot.NewBot(cfg, userService, trackerService, notificationService, channelService, exs.List(), commands)
 	if err != nil {
 		log.Fatal("Error starting bot: ", err)
 	}
@@ -45,14 +59,15 @@ func main() {
 	if err != nil {
 		log.Fatal("Error starting rabbitmq: ", err)
 	}
-	if err := rabbit.DeclareExchange("notifications"); err != nil {
+	if err := rabbit.DeclareTopicExchange(services.NotifyExchange); err != nil {
 		log.Fatal("Error declaring exchange: ", err)
 	}
-	queueName, err := rabbit.QueueBindNDeclare()
-	if err != nil {
+	if err := rabbit.DeclareQueue(services.NotifyExchange, rabbitmq.QueueTelegram, services.NotificationBindings...); err != nil {
 		log.Fatal("Error declaring queue: ", err)
 	}
-	rabbit.StartConsuming(queueName, tgbot.HandleNotification)
+	if err := rabbit.StartConsuming(rabbitmq.QueueTelegram, tgbot.HandleNotification); err != nil {
+		log.Fatal("Error consuming notifications: ", err)
+	}
 
 	tgbot.Start()
 
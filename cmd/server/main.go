@@ -8,6 +8,7 @@ import (
 	"p2pbot/internal/app"
 	"p2pbot/internal/db/repository"
 	"p2pbot/internal/handlers"
+	"p2pbot/internal/rabbitmq"
 	"p2pbot/internal/rediscl"
 	"p2pbot/internal/services"
 	"p2pbot/internal/utils"
@@ -27,26 +28,53 @@ func main() {
 		panic(err)
 	}
 
+	exs, err := services.NewExchanges(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	userRepo := repository.NewUserRepository(DB)
 	userService := services.NewUserService(userRepo)
 	trackerRepo := repository.NewTrackerRepository(DB)
-	trackerService := services.NewTrackerService(trackerRepo)
+	trackerService := services.NewTrackerService(trackerRepo, exs)
 	subscriptionRepo := repository.NewSubscriptionRepository(DB)
 	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
-
-	binance := services.NewBinanceExchange(cfg)
-	bybit := services.NewBybitExcahnge(cfg)
+	marketRepo := repository.NewMarketRepository(DB)
+	marketService := services.NewMarketService(marketRepo)
+	arbitrageRepo := repository.NewArbitrageRepository(DB)
+	arbitrageService := services.NewArbitrageService(arbitrageRepo, exs, cfg)
+	notificationRepo := repository.NewNotificationRepository(DB)
+	notificationService := services.NewNotificationService(notificationRepo)
+	channelRepo := repository.NewChannelRepository(DB)
+	channelService := services.NewChannelService(channelRepo)
+	webhookRepo := repository.NewWebhookRepository(DB)
+	webhookService := services.NewWebhookService(webhookRepo)
+	settingsRepo := repository.NewSettingsRepository(DB)
+	settingsService := services.NewSettingsService(settingsRepo)
 
 	rediscl.InitRedisClient(cfg.Redis.Host, cfg.Redis.Port)
 
+	// user events for dashboard
+	events, err := rabbitmq.NewRabbitMQ(cfg)
+	if err != nil {
+		log.Fatal("Error starting rabbitmq: ", err)
+	}
+	if err := events.DeclareTopicExchange(services.EventsExchange); err != nil {
+		log.Fatal("Error declaring exchange: ", err)
+	}
+
 	controller := handlers.NewController(
 		userService,
 		trackerService,
 		subscriptionService,
-		map[string]services.ExchangeI{
-			"binance": binance,
-			"bybit":   bybit,
-		},
+		marketService,
+		arbitrageService,
+		notificationService,
+		channelService,
+		webhookService,
+		settingsService,
+		events,
+		exs,
 		cfg,
 	)
 
@@ -79,6 +107,15 @@ func main() {
 	publicGroup.GET("/csrf", controller.GetCSRFToken)
 	// Route for payment webhook
 	publicGroup.POST("/subscriptions/confirm", controller.ConfirmOrder)
+	// Unsubscribe link of notification emails, POST is one-click unsubscribe
+	publicGroup.GET("/unsubscribe", controller.ConfirmUnsubscribe)
+	publicGroup.POST("/unsubscribe", controller.Unsubscribe)
+	// JSON schema of notification messages
+	publicGroup.GET("/schemas/notification.json", controller.GetNotificationSchema)
+
+	// Live streams accept access token from query, browsers can't set their headers
+	e.GET("/api/v1/private/events", controller.GetEvents, utils.CheckStreamJWT, utils.ExtractEmail)
+	e.GET("/api/v1/private/books/ws", controller.GetOrderBookFeed, utils.CheckStreamJWT, utils.ExtractEmail)
 
 	privateGroup := e.Group("/api/v1/private")
 
@@ -97,6 +134,35 @@ func main() {
 	privateGroup.GET("/trackers/options/methods", controller.GetPaymentMethods)
 	privateGroup.GET("/trackers/options/currencies", controller.GetCurrencies)
 	privateGroup.GET("/trackers/options/exchanges", controller.GetExchanges)
+	privateGroup.GET("/trackers/options/assets", controller.GetAssets)
+	// Team accounts, never count as outbidders
+	privateGroup.GET("/team", controller.GetTeam)
+	privateGroup.PUT("/team", controller.UpdateTeam)
+	// Arbitrage routes
+	privateGroup.GET("/arbitrages", controller.GetArbitrages)
+	privateGroup.POST("/arbitrages", controller.CreateArbitrage)
+	privateGroup.GET("/arbitrages/:id", controller.GetArbitrage)
+	privateGroup.DELETE("/arbitrages/:id", controller.DeleteArbitrage)
+	privateGroup.PATCH("/arbitrages/:id", controller.UpdateArbitrage)
+	// Notification channel routes
+	privateGroup.GET("/channels", controller.GetChannels)
+	privateGroup.POST("/channels", controller.CreateChannel)
+	privateGroup.PATCH("/channels/:id", controller.UpdateChannel)
+	privateGroup.DELETE("/channels/:id", controller.DeleteChannel)
+	// Outgoing webhook routes
+	privateGroup.GET("/webhooks", controller.GetWebhooks)
+	privateGroup.POST("/webhooks", controller.CreateWebhook)
+	privateGroup.PATCH("/webhooks/:id", controller.UpdateWebhook)
+	privateGroup.DELETE("/webhooks/:id", controller.DeleteWebhook)
+	privateGroup.POST("/webhooks/:id/test", controller.TestWebhook)
+	privateGroup.GET("/webhooks/:id/dead-letters", controller.GetWebhookDeadLetters)
+	// Quiet hours, hourly limit and preferred channels
+	privateGroup.GET("/settings", controller.GetSettings)
+	privateGroup.PUT("/settings", controller.UpdateSettings)
+	// Notification history
+	privateGroup.GET("/notifications", controller.GetNotifications)
+	// Market history
+	privateGroup.GET("/market/history", controller.GetMarketHistory)
 	// User routes
 	privateGroup.GET("/profile", controller.GetProfile)
 	// connect telegram route
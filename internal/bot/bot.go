@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"github.com/rs/zerolog/log"
 	"p2pbot/internal/config"
+	"p2pbot/internal/fsm"
+	"p2pbot/internal/rabbitmq"
 	"p2pbot/internal/rediscl"
 	"p2pbot/internal/services"
 	"strconv"
 	"strings"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	"github.com/lib/pq"
@@ -15,29 +18,58 @@ import (
 )
 
 type Bot struct {
-	api            *tgbotapi.BotAPI
-	userService    *services.UserService
-	trackerService *services.TrackerService
-	NotificationCh chan services.Notification
-	exchanges      []services.ExchangeI
-	toDelete       []int
+	api                 *tgbotapi.BotAPI
+	cfg                 *config.Config
+	userService         *services.UserService
+	trackerService      *services.TrackerService
+	notificationService *services.NotificationService
+	channelService      *services.ChannelService
+	NotificationCh      chan services.Notification
+	exchanges           []services.ExchangeI
+	toDelete            []int
+	// fsm drives new tracker conversations
+	fsm *fsm.FSM
+	// commands exchange, used to send commands to observer
+	commands *rabbitmq.RabbitMQ
 }
 
-func NewBot(cfg *config.Config, userSvc *services.UserService, trackerSvc *services.TrackerService, exs []services.ExchangeI) (*Bot, error) {
+func NewBot(cfg *config.Config,
+	userSvc *services.UserService,
+	trackerSvc *services.TrackerService,
+	notificationSvc *services.NotificationService,
+	channelSvc *services.ChannelService,
+	exs []services.ExchangeI,
+	commands *rabbitmq.RabbitMQ) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(cfg.Telegram.APIkey)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Bot{
-		api:            api,
-		userService:    userSvc,
-		trackerService: trackerSvc,
-		NotificationCh: make(chan services.Notification),
-		exchanges:      exs}, nil
+		api:                 api,
+		cfg:                 cfg,
+		userService:         userSvc,
+		trackerService:      trackerSvc,
+		notificationService: notificationSvc,
+		channelService:      channelSvc,
+		NotificationCh:      make(chan services.Notification),
+		exchanges:           exs,
+		commands:            commands,
+		fsm: fsm.New(fsm.NewRedisStore(services.StagingTTL),
+			time.Duration(cfg.Telegram.ConversationTimeout)*time.Second)}, nil
 }
 
+// Start receives updates with mode from config, polling by default
 func (bot *Bot) Start() {
+	if bot.cfg.Telegram.Mode == ModeWebhook {
+		err := bot.StartWebhook(bot.cfg.Telegram.WebhookURL, bot.cfg.Telegram.SecretToken, bot.cfg.Telegram.ListenAddr)
+		log.Fatal().Err(err).Msg("Webhook listener stopped")
+	}
+	// Updates are not delivered with getUpdates while webhook is set
+	if _, err := bot.api.RemoveWebhook(); err != nil {
+		log.Error().Err(err).Msg("Failed to remove webhook")
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 60
 
@@ -47,25 +79,46 @@ func (bot *Bot) Start() {
 	}
 
 	for update := range updates {
-		if update.Message == nil {
-			continue
-		}
-		chatID := update.Message.Chat.ID
-
-		switch update.Message.Command() {
-		case "start":
-			err := bot.HandleStart(update.Message)
-			if err != nil {
-				log.Error().Fields(map[string]interface{}{
-					"error": err.Error(),
-				}).Msg("bot message")
-			}
-		default:
-			bot.SendMessage(chatID, "Unknown command")
-		}
+		bot.HandleUpdate(update)
+	}
+}
+
+// HandleMessage routes commands, other text is an answer in conversation
+func (bot *Bot) HandleMessage(msg *tgbotapi.Message) error {
+	switch msg.Command() {
+	case "start":
+		return bot.HandleStart(msg)
+	case "history":
+		return bot.HandleHistory(msg)
+	case "newtracker":
+		return bot.HandleNewTracker(msg)
+	case "cancel":
+		return bot.HandleCancel(msg.Chat.ID)
+	case "trackers":
+		return bot.HandleTrackers(msg)
+	case "delete":
+		return bot.HandleTrackerCommand(msg, cbDelete)
+	case "mute":
+		return bot.HandleTrackerCommand(msg, cbMute)
+	case "unmute":
+		return bot.HandleTrackerCommand(msg, cbUnmute)
+	case "":
+		return bot.HandleText(msg)
+	default:
+		bot.SendMessage(msg.Chat.ID, "Unknown command")
+		return nil
 	}
 }
 
+const helpText = `Commands:
+/newtracker - track your advertisement
+/trackers - list your trackers
+/delete - delete tracker
+/mute - stop notifications of tracker
+/unmute - resume notifications of tracker
+/history - latest notifications
+/cancel - cancel current action`
+
 func (bot *Bot) HandleStart(msg *tgbotapi.Message) error {
 	args := strings.Split(msg.CommandArguments(), " ")
 	// Send different start message if unique_code is provided
@@ -76,8 +129,7 @@ func (bot *Bot) HandleStart(msg *tgbotapi.Message) error {
 		ctx := rediscl.RDB.Ctx
 		userID, err := rediscl.RDB.Client.Get(ctx, "telegram_codes:"+code).Result()
 		if userID == "" || err == redis.Nil {
-			bot.SendMessage(msg.Chat.ID, "Link doesn't exist or expired")
-			return nil
+			return bot.HandleChannelLink(msg, code)
 		}
 		if err != nil {
 			return err
@@ -105,25 +157,50 @@ func (bot *Bot) HandleStart(msg *tgbotapi.Message) error {
 		bot.SendMessage(msg.Chat.ID, "Successfully connected")
 		return nil
 	} else {
-		// TODO handle default /start command
-		bot.SendMessage(msg.Chat.ID, "TODO, /start command")
+		bot.SendMessage(msg.Chat.ID, helpText)
 		return nil
 	}
 }
 
+// HandleChannelLink links chat of message as notification channel with code of link
+func (bot *Bot) HandleChannelLink(msg *tgbotapi.Message, code string) error {
+	ch, err := bot.channelService.LinkTelegram(code, msg.Chat.ID)
+	if err, ok := err.(*pq.Error); ok && err.Code == "23505" {
+		bot.SendMessage(msg.Chat.ID, "This chat is already connected")
+		return nil
+	}
+	if err != nil {
+		bot.SendMessage(msg.Chat.ID, "Chat could not be connected")
+		return err
+	}
+	if ch == nil {
+		bot.SendMessage(msg.Chat.ID, "Link doesn't exist or expired")
+		return nil
+	}
+	bot.SendMessage(msg.Chat.ID, "Chat connected, notifications will be sent here")
+	return nil
+}
+
 // 2. get user_id from redis, telegram_codes:unique_code
 // 3. if user_id exists, extract chat_id from message and set user.chat_id, otherwise send link expired message
 // 4. delete unique_code from redis
 
 func (bot *Bot) SendMessage(chatID int64, text string) int {
-	msg := tgbotapi.NewMessage(chatID, text)
-	msgSent, err := bot.api.Send(msg)
+	msgID, err := bot.send(chatID, text)
 	if err != nil {
 		log.Printf("Failed to send message: %v", err)
-		return 0
 	}
+	return msgID
+}
 
-	return msgSent.MessageID
+// send sends text message and returns its id
+func (bot *Bot) send(chatID int64, text string) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msgSent, err := bot.api.Send(msg)
+	if err != nil {
+		return 0, err
+	}
+	return msgSent.MessageID, nil
 }
 
 func (bot *Bot) SendMultiple(ids []int64, text string) {
@@ -192,8 +269,8 @@ func (bot *Bot) NotifyUsers() {
 	for notification := range bot.NotificationCh {
 		q, minAmount, maxAmount := notification.Data.GetQuantity()
 		c := notification.Currency
-		msg := fmt.Sprintf("Your %s order on %s was outbided by %s.\nPrice: %.3f %s\nQuantity: %.2fUSDT\nMin: %.2f%s\nMax: %.2f%s",
-			notification.Side, notification.Exchange, notification.Data.GetName(), notification.Data.GetPrice(), c, q, minAmount, c, maxAmount, c)
+		msg := fmt.Sprintf("Your %s order on %s was outbided by %s.\nPrice: %.3f %s\nQuantity: %.2f%s\nMin: %.2f%s\nMax: %.2f%s",
+			notification.Side, notification.Exchange, notification.Data.GetName(), notification.Data.GetPrice(), c, q, notification.Asset, minAmount, c, maxAmount, c)
 
 		bot.SendMessage(notification.ChatID, msg)
 		bot.SendMessage(notification.ChatID, "You won't get notifications until you update your order")
@@ -1,48 +1,221 @@
 package bot
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/rs/zerolog/log"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/rabbitmq"
 	"p2pbot/internal/services"
 	"strings"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-func (bot *Bot) HandleNotification(msg amqp.Delivery) {
-	if msg.ContentType == "application/json" {
-		var n services.Notification
-		if err := json.Unmarshal(msg.Body, &n); err != nil {
-			log.Error().Msg(err.Error())
-			return
-		}
-		q, minA, maxA := n.Data.GetQuantity()
-		price := n.Data.GetPrice()
-		name := n.Data.GetName()
-		pms := strings.Join(n.Data.GetPaymentMethods(), ", ")
-
-		template := `Your %s %s advertisement on %s was outbided by %s.
+// HandleNotification sends notification to connected telegram,
+// failed sends are returned to be retried unless telegram rejected them for good
+func (bot *Bot) HandleNotification(msg amqp.Delivery) error {
+	if msg.ContentType != "application/json" {
+		return fmt.Errorf("%w: invalid content type %s", rabbitmq.ErrPoison, msg.ContentType)
+	}
+	n, err := services.DecodeNotification(msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPoison, err)
+	}
+	// Telegram not connected, delivered by notifier to linked channels
+	if n.ChatID == 0 {
+		return nil
+	}
+	tgMsg := tgbotapi.NewMessage(n.ChatID, FormatNotification(n))
+	if keyboard := NotificationKeyboard(n); keyboard != nil {
+		tgMsg.ReplyMarkup = *keyboard
+	}
+	var msgID int
+	msgSent, err := bot.api.Send(tgMsg)
+	if err != nil {
+		log.Printf("Failed to send message: %v", err)
+	} else {
+		msgID = msgSent.MessageID
+	}
+	bot.updateDelivery(n, msgID, err)
+	if permanentError(err) {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPoison, err)
+	}
+	return err
+}
+
+/*
+permanentError reports if telegram rejected message for reason retries can't fix,
+like "Forbidden: bot was blocked by the user" or "Bad Request: chat not found".
+Network errors and "Too Many Requests" are temporary
+*/
+func permanentError(err error) bool {
+	var apiErr tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return strings.HasPrefix(apiErr.Message, "Forbidden") || strings.HasPrefix(apiErr.Message, "Bad Request")
+}
+
+// updateDelivery stores delivery result in notification log,
+// notifications published before delivery log have no ID
+func (bot *Bot) updateDelivery(n services.Notification, msgID int, sendErr error) {
+	if n.ID == 0 {
+		return
+	}
+	var err error
+	if sendErr != nil {
+		err = bot.notificationService.MarkFailed(n.ID, sendErr)
+	} else {
+		err = bot.notificationService.MarkSent(n.ID, msgID)
+	}
+	if err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+			"id":    n.ID,
+		}).Msg("Error updating notification status")
+	}
+}
+
+// historySize is number of notifications shown by /history
+const historySize = 10
+
+// HandleHistory sends user's latest notifications
+func (bot *Bot) HandleHistory(msg *tgbotapi.Message) error {
+	user, err := bot.userService.GetUserByChatID(msg.Chat.ID)
+	if err != nil {
+		bot.SendMessage(msg.Chat.ID, "Telegram is not connected to p2phub account")
+		return nil
+	}
+	history, _, err := bot.notificationService.GetHistory(user.ID, models.NotificationFilter{Limit: historySize})
+	if err != nil {
+		return err
+	}
+	bot.SendMessage(msg.Chat.ID, FormatHistory(history))
+	return nil
+}
+
+// FormatHistory creates telegram message text for notification history
+func FormatHistory(history []*models.Notification) string {
+	if len(history) == 0 {
+		return "You have no notifications yet"
+	}
+	lines := make([]string, 0, len(history)+1)
+	lines = append(lines, fmt.Sprintf("Last %d notifications:", len(history)))
+	for _, n := range history {
+		lines = append(lines, fmt.Sprintf("%s %s %s %s/%s %s: %s %.2f%s (%s)",
+			n.CreatedAt.Format("02.01 15:04"),
+			n.Kind,
+			n.Exchange,
+			n.Asset,
+			n.Currency,
+			n.Side,
+			n.Competitor,
+			n.Price,
+			n.Currency,
+			n.Status))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FormatNotification creates telegram message text for notification
+func FormatNotification(n services.Notification) string {
+	if n.Kind == services.NotificationKindSummary {
+		return fmt.Sprintf("Quiet hours are over, %d notifications were held:\n%s",
+			len(n.Summary), strings.Join(n.Summary, "\n"))
+	}
+	q, minA, maxA := n.Data.GetQuantity()
+	price := n.Data.GetPrice()
+	name := n.Data.GetName()
+	pms := strings.Join(n.PaymentMethodNames(), ", ")
+
+	switch n.Kind {
+	case models.TrackerKindPrice:
+		template := `Best %s/%s %s price on %s is %s %.2f%s.
+Price: %.2f%s by %s.
 Payment methods: %s.
-Quantity: %.2fUSDT.
-Min. amount: %.1f%s | Max. amount: %.1f%s.
-Price: %.2f%s`
-		message := fmt.Sprintf(
+Quantity: %.2f%s.`
+		return fmt.Sprintf(
 			template,
+			n.Asset,
 			n.Currency,
 			n.Side,
 			n.Exchange,
+			n.Direction,
+			n.Threshold,
+			n.Currency,
+			price,
+			n.Currency,
 			name,
 			pms,
 			q,
-			minA,
-			n.Currency,
-			maxA,
+			n.Asset)
+	case models.TrackerKindSpread:
+		template := `Spread for %s/%s %s between %s and %s is %.2f%% (above %.2f%%).
+%s: %.2f%s by %s.
+%s: %.2f%s.`
+		return fmt.Sprintf(
+			template,
+			n.Asset,
 			n.Currency,
+			n.Side,
+			n.Exchange,
+			n.CompareExchange,
+			n.Spread,
+			n.Threshold,
+			n.Exchange,
 			price,
+			n.Currency,
+			name,
+			n.CompareExchange,
+			n.ComparePrice,
 			n.Currency)
-		bot.SendMessage(n.ChatID, message)
-	} else {
-		log.Error().Str("msg body", string(msg.Body)).Msg("Invalid content type")
+	case services.NotificationKindArbitrage:
+		template := `Arbitrage %s/%s: buy on %s, sell on %s, spread after fees %.2f%% (above %.2f%%).
+Buy: %.2f%s by %s.
+Sell: %.2f%s by %s.
+Payment methods: %s.
+Quantity: %.2f%s.`
+		return fmt.Sprintf(
+			template,
+			n.Asset,
+			n.Currency,
+			n.Exchange,
+			n.CompareExchange,
+			n.Spread,
+			n.Threshold,
+			price,
+			n.Currency,
+			name,
+			n.ComparePrice,
+			n.Currency,
+			n.CompareName,
+			strings.Join(n.Methods, ", "),
+			q,
+			n.Asset)
 	}
+
+	template := `Your %s/%s %s advertisement on %s was outbided by %s (%s).
+Payment methods: %s.
+Quantity: %.2f%s.
+Min. amount: %.1f%s | Max. amount: %.1f%s.
+Price: %.2f%s`
+	return fmt.Sprintf(
+		template,
+		n.Asset,
+		n.Currency,
+		n.Side,
+		n.Exchange,
+		name,
+		services.AdvertiserSummary(n.Data.ToAdvertisement().Advertiser),
+		pms,
+		q,
+		n.Asset,
+		minA,
+		n.Currency,
+		maxA,
+		n.Currency,
+		price,
+		n.Currency)
 }
@@ -0,0 +1,26 @@
+package bot
+
+import (
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+func TestPermanentError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{tgbotapi.Error{Message: "Forbidden: bot was blocked by the user"}, true},
+		{tgbotapi.Error{Message: "Bad Request: chat not found"}, true},
+		{tgbotapi.Error{Message: "Too Many Requests: retry after 5"}, false},
+		{fmt.Errorf("connection reset by peer"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := permanentError(tt.err); got != tt.want {
+			t.Errorf("%v: expected %v, got %v", tt.err, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,482 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/fsm"
+	"p2pbot/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Callback data prefixes of inline keyboards
+const (
+	cbExchange = "ex:"
+	cbSide     = "side:"
+	cbDelete   = "delete:"
+	cbMute     = "mute:"
+	cbUnmute   = "unmute:"
+	cbCancel   = "cancel"
+	// Notification keyboard
+	cbMuteHour = "mute1h:"
+	cbBook     = "book:"
+	cbUpdated  = "updated:"
+)
+
+// bookSize is number of advertisements shown by "Open ad book"
+const bookSize = 5
+
+// chatUser returns user connected to telegram chat,
+// sends connect hint and returns nil if chat is not connected
+func (bot *Bot) chatUser(chatID int64) *models.User {
+	user, err := bot.userService.GetUserByChatID(chatID)
+	if err != nil {
+		bot.SendMessage(chatID, "Telegram is not connected to p2phub account")
+		return nil
+	}
+	return user
+}
+
+// sendKeyboard sends text message with inline keyboard
+func (bot *Bot) sendKeyboard(chatID int64, text string, keyboard tgbotapi.InlineKeyboardMarkup) int {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	msgSent, err := bot.api.Send(msg)
+	if err != nil {
+		log.Printf("Failed to send message: %v", err)
+		return 0
+	}
+	return msgSent.MessageID
+}
+
+func cancelRow() []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("Cancel", cbCancel))
+}
+
+// HandleNewTracker starts new tracker conversation:
+// exchange -> currency -> username -> side
+func (bot *Bot) HandleNewTracker(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	// Drop unfinished conversation
+	bot.fsm.Transition(chatID, fsm.Cancel)
+	if err := bot.trackerService.DeleteTrackerStaging(user.ID); err != nil {
+		return err
+	}
+	if _, err := bot.fsm.Transition(chatID, fsm.NewTracker); err != nil {
+		return err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(bot.exchanges)+1)
+	for _, ex := range bot.exchanges {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(ex.GetName(), cbExchange+strings.ToLower(ex.GetName()))))
+	}
+	rows = append(rows, cancelRow())
+	bot.sendKeyboard(chatID, "Choose exchange", tgbotapi.NewInlineKeyboardMarkup(rows...))
+	return nil
+}
+
+// HandleCancel stops new tracker conversation
+func (bot *Bot) HandleCancel(chatID int64) error {
+	if user, err := bot.userService.GetUserByChatID(chatID); err == nil {
+		if err := bot.trackerService.DeleteTrackerStaging(user.ID); err != nil {
+			return err
+		}
+	}
+	if _, err := bot.fsm.Transition(chatID, fsm.Cancel); err != nil {
+		return err
+	}
+	bot.SendMessage(chatID, "Cancelled")
+	return nil
+}
+
+// HandleText handles answers in new tracker conversation
+func (bot *Bot) HandleText(msg *tgbotapi.Message) error {
+	chatID := msg.Chat.ID
+	state := bot.fsm.GetState(chatID)
+	if state == fsm.Welcome {
+		bot.SendMessage(chatID, "Unknown command")
+		return nil
+	}
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	tracker, err := bot.trackerService.GetTrackerStaging(user.ID)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(msg.Text)
+
+	switch state {
+	case fsm.AwaitingСurrency:
+		currency := strings.ToUpper(text)
+		if len(currency) != 3 {
+			bot.SendMessage(chatID, "Currency ticker must be 3 symbols long, EUR for example")
+			return nil
+		}
+		if _, err := bot.fsm.Transition(chatID, fsm.CurrencyGiven, currency, tracker); err != nil {
+			return err
+		}
+		if err := bot.trackerService.SaveTrackerStaging(user.ID, tracker); err != nil {
+			return err
+		}
+		bot.SendMessage(chatID, fmt.Sprintf("Send your username on %s", tracker.Exchange))
+	case fsm.AwaitingExchangeUsername:
+		if text == "" {
+			bot.SendMessage(chatID, "Username must not be empty")
+			return nil
+		}
+		if _, err := bot.fsm.Transition(chatID, fsm.UsernameGiven, text, tracker); err != nil {
+			return err
+		}
+		if err := bot.trackerService.SaveTrackerStaging(user.ID, tracker); err != nil {
+			return err
+		}
+		bot.sendKeyboard(chatID, "Choose side of your advertisement", tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("BUY", cbSide+"BUY"),
+				tgbotapi.NewInlineKeyboardButtonData("SELL", cbSide+"SELL"),
+			),
+			cancelRow(),
+		))
+	default:
+		bot.SendMessage(chatID, "Choose option from keyboard above or /cancel")
+	}
+	return nil
+}
+
+// HandleCallback handles inline keyboard buttons
+func (bot *Bot) HandleCallback(q *tgbotapi.CallbackQuery) error {
+	if q.Message == nil {
+		return nil
+	}
+	chatID := q.Message.Chat.ID
+	if _, err := bot.api.AnswerCallbackQuery(tgbotapi.NewCallback(q.ID, "")); err != nil {
+		log.Printf("Failed to answer callback: %v", err)
+	}
+
+	switch {
+	case q.Data == cbCancel:
+		return bot.HandleCancel(chatID)
+	case strings.HasPrefix(q.Data, cbExchange):
+		return bot.handleExchangeChosen(chatID, strings.TrimPrefix(q.Data, cbExchange))
+	case strings.HasPrefix(q.Data, cbSide):
+		return bot.handleSideChosen(chatID, strings.TrimPrefix(q.Data, cbSide))
+	case strings.HasPrefix(q.Data, cbDelete):
+		return bot.handleTrackerAction(chatID, cbDelete, strings.TrimPrefix(q.Data, cbDelete))
+	case strings.HasPrefix(q.Data, cbMute):
+		return bot.handleTrackerAction(chatID, cbMute, strings.TrimPrefix(q.Data, cbMute))
+	case strings.HasPrefix(q.Data, cbUnmute):
+		return bot.handleTrackerAction(chatID, cbUnmute, strings.TrimPrefix(q.Data, cbUnmute))
+	case strings.HasPrefix(q.Data, cbMuteHour):
+		return bot.handleTrackerAction(chatID, cbMuteHour, strings.TrimPrefix(q.Data, cbMuteHour))
+	case strings.HasPrefix(q.Data, cbBook):
+		return bot.handleTrackerAction(chatID, cbBook, strings.TrimPrefix(q.Data, cbBook))
+	case strings.HasPrefix(q.Data, cbUpdated):
+		return bot.handleTrackerAction(chatID, cbUpdated, strings.TrimPrefix(q.Data, cbUpdated))
+	}
+	return fmt.Errorf("unknown callback %s", q.Data)
+}
+
+func (bot *Bot) getExchange(name string) services.ExchangeI {
+	for _, ex := range bot.exchanges {
+		if strings.ToLower(ex.GetName()) == name {
+			return ex
+		}
+	}
+	return nil
+}
+
+func (bot *Bot) handleExchangeChosen(chatID int64, name string) error {
+	if bot.fsm.GetState(chatID) != fsm.AwaitingExchange {
+		bot.SendMessage(chatID, "Start with /newtracker")
+		return nil
+	}
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	tracker, err := bot.trackerService.GetTrackerStaging(user.ID)
+	if err != nil {
+		return err
+	}
+	if bot.getExchange(name) == nil {
+		bot.fsm.Transition(chatID, fsm.ExchangeNotFound)
+		bot.SendMessage(chatID, fmt.Sprintf("Exchange %s not supported", name))
+		return nil
+	}
+	if _, err := bot.fsm.Transition(chatID, fsm.ExchangeFound, int64(user.ID), name, tracker); err != nil {
+		return err
+	}
+	if err := bot.trackerService.SaveTrackerStaging(user.ID, tracker); err != nil {
+		return err
+	}
+	bot.SendMessage(chatID, "Send fiat currency, EUR for example")
+	return nil
+}
+
+// handleSideChosen finds user's advertisements and creates trackers for them
+func (bot *Bot) handleSideChosen(chatID int64, side string) error {
+	if bot.fsm.GetState(chatID) != fsm.AwaitingSide {
+		bot.SendMessage(chatID, "Start with /newtracker")
+		return nil
+	}
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	tracker, err := bot.trackerService.GetTrackerStaging(user.ID)
+	if err != nil {
+		return err
+	}
+	tracker.Side = side
+	tracker.Notify = true
+	tracker.IsAggregated = true
+
+	if err := bot.trackerService.ValidateTracker(tracker, false); err != nil {
+		bot.fsm.Transition(chatID, fsm.AdvertisementNotFound)
+		bot.trackerService.DeleteTrackerStaging(user.ID)
+		bot.SendMessage(chatID, fmt.Sprintf("Invalid tracker: %s. Try again /newtracker", err.Error()))
+		return nil
+	}
+	exchange := bot.getExchange(tracker.Exchange)
+	ads, err := exchange.GetAdsByName(tracker.Asset, tracker.Currency, tracker.Side, tracker.Username, []string{})
+	if err != nil {
+		bot.fsm.Transition(chatID, fsm.AdvertisementNotFound)
+		bot.trackerService.DeleteTrackerStaging(user.ID)
+		bot.SendMessage(chatID, fmt.Sprintf("%s. Try again /newtracker", err.Error()))
+		return nil
+	}
+	// needed for retreiving payment methods names from ids
+	pMethods, err := exchange.GetCachedPaymentMethods(tracker.Currency)
+	if err != nil {
+		return err
+	}
+
+	created := make([]string, 0, len(ads))
+	for _, adv := range ads {
+		tracker.ID = 0
+		tracker.Price = adv.GetPrice()
+		tracker.Payment = make([]*models.PaymentMethod, 0)
+		for _, p := range adv.GetPaymentMethods() {
+			name, err := services.GetPMethodName(pMethods, p)
+			if err != nil {
+				name = p
+			}
+			tracker.Payment = append(tracker.Payment, &models.PaymentMethod{Id: p, Name: name})
+		}
+		if err := bot.trackerService.CreateTracker(tracker); err != nil {
+			return err
+		}
+		created = append(created, fmt.Sprintf("#%d %.2f%s", tracker.ID, tracker.Price, tracker.Currency))
+	}
+	bot.fsm.Transition(chatID, fsm.AdvertisementFound)
+	bot.trackerService.DeleteTrackerStaging(user.ID)
+	bot.SendMessage(chatID, "Trackers created:\n"+strings.Join(created, "\n"))
+	return nil
+}
+
+// FormatTracker creates one line description of tracker
+func FormatTracker(t *models.UserTracker) string {
+	line := fmt.Sprintf("#%d %s %s/%s %s %s", t.ID, t.Exchange, t.Asset, t.Currency, t.Side, t.Kind)
+	if t.Username != "" {
+		line += " " + t.Username
+	}
+	if !t.Notify {
+		line += " (muted)"
+	}
+	return line
+}
+
+// HandleTrackers sends list of user's trackers
+func (bot *Bot) HandleTrackers(msg *tgbotapi.Message) error {
+	user := bot.chatUser(msg.Chat.ID)
+	if user == nil {
+		return nil
+	}
+	trackers, err := bot.trackerService.GetTrackersByUserId(user.ID)
+	if err != nil {
+		return err
+	}
+	if len(trackers) == 0 {
+		bot.SendMessage(msg.Chat.ID, "You have no trackers, create one with /newtracker")
+		return nil
+	}
+	lines := make([]string, 0, len(trackers))
+	for _, t := range trackers {
+		lines = append(lines, FormatTracker(t))
+	}
+	bot.SendMessage(msg.Chat.ID, "Your trackers:\n"+strings.Join(lines, "\n"))
+	return nil
+}
+
+/*
+HandleTrackerCommand handles /delete, /mute and /unmute.
+Tracker id can be given as argument, otherwise keyboard with
+suitable trackers is sent
+*/
+func (bot *Bot) HandleTrackerCommand(msg *tgbotapi.Message, action string) error {
+	chatID := msg.Chat.ID
+	if arg := strings.TrimPrefix(strings.TrimSpace(msg.CommandArguments()), "#"); arg != "" {
+		return bot.handleTrackerAction(chatID, action, arg)
+	}
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	trackers, err := bot.trackerService.GetTrackersByUserId(user.ID)
+	if err != nil {
+		return err
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(trackers)+1)
+	for _, t := range trackers {
+		// Only muted trackers can be unmuted and vice versa
+		if (action == cbMute && !t.Notify) || (action == cbUnmute && t.Notify) {
+			continue
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(FormatTracker(t), action+strconv.FormatInt(t.ID, 10))))
+	}
+	if len(rows) == 0 {
+		bot.SendMessage(chatID, "No suitable trackers")
+		return nil
+	}
+	rows = append(rows, cancelRow())
+	bot.sendKeyboard(chatID, "Choose tracker", tgbotapi.NewInlineKeyboardMarkup(rows...))
+	return nil
+}
+
+// handleTrackerAction deletes, mutes or unmutes user's tracker,
+// handles buttons of notification keyboard
+func (bot *Bot) handleTrackerAction(chatID int64, action, arg string) error {
+	user := bot.chatUser(chatID)
+	if user == nil {
+		return nil
+	}
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		bot.SendMessage(chatID, "Invalid tracker ID")
+		return nil
+	}
+	tracker, err := bot.trackerService.GetTrackerById(id)
+	// Check if tracker created by user
+	if err != nil || tracker.UserID != user.ID {
+		bot.SendMessage(chatID, "Tracker not found")
+		return nil
+	}
+
+	switch action {
+	case cbDelete:
+		if err := bot.trackerService.DeleteTracker(id); err != nil {
+			return err
+		}
+		bot.SendMessage(chatID, fmt.Sprintf("Tracker #%d deleted", id))
+	case cbMute, cbUnmute:
+		notify := action == cbUnmute
+		if err := bot.trackerService.SetNotify(tracker.ID, notify); err != nil {
+			return err
+		}
+		if notify {
+			bot.SendMessage(chatID, fmt.Sprintf("Tracker #%d unmuted", id))
+		} else {
+			bot.SendMessage(chatID, fmt.Sprintf("Tracker #%d muted", id))
+		}
+	case cbMuteHour:
+		if err := bot.trackerService.MuteTracker(tracker.ID, time.Hour); err != nil {
+			return err
+		}
+		bot.SendMessage(chatID, fmt.Sprintf("Tracker #%d muted for 1 hour", id))
+	case cbBook:
+		exchange := bot.getExchange(tracker.Exchange)
+		if exchange == nil {
+			bot.SendMessage(chatID, fmt.Sprintf("Exchange %s not supported", tracker.Exchange))
+			return nil
+		}
+		ads, err := exchange.GetAds(tracker.Asset, tracker.Currency, tracker.Side)
+		if err != nil {
+			return err
+		}
+		bot.SendMessage(chatID, FormatBook(tracker, ads))
+	case cbUpdated:
+		// Outbid flags are reset, so user is notified again if still outbidded
+		if err := bot.trackerService.ResetOutbid(tracker.ID); err != nil {
+			return err
+		}
+		if err := bot.publishCommand(services.Command{
+			Kind:      services.CommandCheckTracker,
+			TrackerID: tracker.ID,
+		}); err != nil {
+			return err
+		}
+		bot.SendMessage(chatID, fmt.Sprintf("Tracker #%d will be checked now", id))
+	}
+	return nil
+}
+
+// publishCommand sends command to observer
+func (bot *Bot) publishCommand(cmd services.Command) error {
+	if bot.commands == nil {
+		return fmt.Errorf("commands exchange not connected")
+	}
+	body, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	return bot.commands.Publish(body)
+}
+
+// NotificationKeyboard returns inline keyboard for notification of tracker,
+// nil for notifications without tracker(arbitrage)
+func NotificationKeyboard(n services.Notification) *tgbotapi.InlineKeyboardMarkup {
+	if n.TrackerID == 0 {
+		return nil
+	}
+	id := strconv.FormatInt(n.TrackerID, 10)
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Mute 1h", cbMuteHour+id),
+			tgbotapi.NewInlineKeyboardButtonData("Pause tracker", cbMute+id),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Open ad book", cbBook+id),
+		),
+	}
+	// Only outbid trackers wait for price update
+	if n.Kind == "" || n.Kind == models.TrackerKindOutbid {
+		rows[1] = append(rows[1], tgbotapi.NewInlineKeyboardButtonData("I've updated my price", cbUpdated+id))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &keyboard
+}
+
+// FormatBook creates telegram message text with best advertisements of tracker book
+func FormatBook(tracker *models.Tracker, ads []services.P2PItemI) string {
+	if len(ads) == 0 {
+		return "No advertisements found"
+	}
+	lines := make([]string, 0, bookSize+1)
+	lines = append(lines, fmt.Sprintf("%s %s/%s %s:", tracker.Exchange, tracker.Asset, tracker.Currency, tracker.Side))
+	for i, ad := range ads {
+		if i == bookSize {
+			break
+		}
+		q, _, _ := ad.GetQuantity()
+		line := fmt.Sprintf("%d. %.2f%s by %s, %.2f%s", i+1, ad.GetPrice(), tracker.Currency, ad.GetName(), q, tracker.Asset)
+		if ad.GetName() == tracker.Username {
+			line += " (you)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
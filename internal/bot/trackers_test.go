@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strings"
+	"testing"
+)
+
+func TestNotificationKeyboard(t *testing.T) {
+	if kb := NotificationKeyboard(services.Notification{Kind: services.NotificationKindArbitrage}); kb != nil {
+		t.Error("expected no keyboard without tracker")
+	}
+
+	kb := NotificationKeyboard(services.Notification{TrackerID: 12})
+	if kb == nil {
+		t.Fatal("expected keyboard for outbid notification")
+	}
+	var data []string
+	for _, row := range kb.InlineKeyboard {
+		for _, button := range row {
+			data = append(data, *button.CallbackData)
+		}
+	}
+	want := []string{"mute1h:12", "mute:12", "book:12", "updated:12"}
+	if strings.Join(data, ",") != strings.Join(want, ",") {
+		t.Errorf("expected buttons %v, got %v", want, data)
+	}
+
+	kb = NotificationKeyboard(services.Notification{TrackerID: 12, Kind: models.TrackerKindPrice})
+	for _, row := range kb.InlineKeyboard {
+		for _, button := range row {
+			if strings.HasPrefix(*button.CallbackData, cbUpdated) {
+				t.Error("price alert must not have price updated button")
+			}
+		}
+	}
+}
+
+func TestFormatBook(t *testing.T) {
+	tracker := &models.Tracker{Exchange: "okx", Asset: "USDT", Currency: "EUR", Side: "SELL", Username: "me"}
+	ads := make([]services.P2PItemI, 0)
+	for _, name := range []string{"a", "me", "b", "c", "d", "e"} {
+		ads = append(ads, services.OkxItem{NickName: name, Price: "0.95", AvailableAmount: "100"})
+	}
+	lines := strings.Split(FormatBook(tracker, ads), "\n")
+	if len(lines) != bookSize+1 {
+		t.Fatalf("expected %d lines, got %d", bookSize+1, len(lines))
+	}
+	if lines[2] != "2. 0.95EUR by me, 100.00USDT (you)" {
+		t.Errorf("unexpected line %q", lines[2])
+	}
+	if FormatBook(tracker, nil) != "No advertisements found" {
+		t.Error("expected empty book message")
+	}
+}
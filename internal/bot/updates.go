@@ -0,0 +1,145 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"p2pbot/internal/rediscl"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// updateTTL is how long handled update ids are remembered
+	updateTTL = time.Hour
+	// chatLockTTL limits how long chat stays locked if replica dies while handling update
+	chatLockTTL = 30 * time.Second
+	// chatLockWait is how long update waits for chat lock held by another replica
+	chatLockWait  = 10 * time.Second
+	chatLockRetry = 100 * time.Millisecond
+)
+
+// unlockScript deletes lock only if it still belongs to the caller
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+/*
+HandleUpdate handles telegram update, safe to call from several bot replicas:
+every update is handled once, updates of one chat are handled one at a time.
+
+Update is claimed only after chat is locked, so returned error means
+update wasn't taken by this replica and can be delivered again
+*/
+func (bot *Bot) HandleUpdate(update tgbotapi.Update) error {
+	var chatID int64
+	switch {
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		chatID = update.CallbackQuery.Message.Chat.ID
+	case update.Message != nil:
+		chatID = update.Message.Chat.ID
+	default:
+		return nil
+	}
+
+	unlock, err := lockChat(chatID)
+	if err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error":   err.Error(),
+			"chat_id": chatID,
+		}).Msg("bot chat lock")
+		return err
+	}
+	defer unlock()
+
+	first, err := claimUpdate(update.UpdateID)
+	if err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error":  err.Error(),
+			"update": update.UpdateID,
+		}).Msg("bot update claim")
+		return err
+	}
+	// Already handled by another replica
+	if !first {
+		return nil
+	}
+
+	if err := bot.expireConversation(chatID); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error":   err.Error(),
+			"chat_id": chatID,
+		}).Msg("bot conversation expiry")
+	}
+
+	if update.CallbackQuery != nil {
+		if err := bot.HandleCallback(update.CallbackQuery); err != nil {
+			log.Error().Fields(map[string]interface{}{
+				"error": err.Error(),
+			}).Msg("bot callback")
+		}
+		return nil
+	}
+	if err := bot.HandleMessage(update.Message); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("bot message")
+	}
+	return nil
+}
+
+// expireConversation ends conversation if user didn't answer in time
+func (bot *Bot) expireConversation(chatID int64) error {
+	expired, err := bot.fsm.Expire(chatID)
+	if err != nil || !expired {
+		return err
+	}
+	if user, err := bot.userService.GetUserByChatID(chatID); err == nil {
+		if err := bot.trackerService.DeleteTrackerStaging(user.ID); err != nil {
+			return err
+		}
+	}
+	bot.SendMessage(chatID, "Conversation expired, start again with /newtracker")
+	return nil
+}
+
+// claimUpdate returns true if update wasn't handled before
+func claimUpdate(updateID int) (bool, error) {
+	return rediscl.RDB.Client.SetNX(rediscl.RDB.Ctx,
+		fmt.Sprintf("tg:update:%d", updateID), 1, updateTTL).Result()
+}
+
+// lockChat waits for chat lock and returns function releasing it
+func lockChat(chatID int64) (func(), error) {
+	key := fmt.Sprintf("lock:chat:%d", chatID)
+	// Random token, so replica can't release lock taken by another one
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(b)
+	deadline := time.Now().Add(chatLockWait)
+	for {
+		ok, err := rediscl.RDB.Client.SetNX(rediscl.RDB.Ctx, key, token, chatLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("chat %d is locked", chatID)
+		}
+		time.Sleep(chatLockRetry)
+	}
+	return func() {
+		if err := unlockScript.Run(rediscl.RDB.Ctx, rediscl.RDB.Client, []string{key}, token).Err(); err != nil {
+			log.Printf("Failed to unlock chat %d: %v", chatID, err)
+		}
+	}, nil
+}
@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"net/url"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// Bot modes set in config.Telegram
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// secretHeader is header with secret token set by setWebhook
+const secretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+/*
+WebhookHandler returns http handler receiving updates from telegram.
+Requests without secret token set by SetWebhook are rejected,
+handle is called for every update before responding to telegram,
+if it fails telegram delivers update again.
+It can be mounted on echo server with echo.WrapHandler
+*/
+func WebhookHandler(secret string, handle func(tgbotapi.Update) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		token := r.Header.Get(secretHeader)
+		if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			log.Error().Str("remote", r.RemoteAddr).Msg("Webhook request with invalid secret token")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var update tgbotapi.Update
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if err := handle(update); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// SetWebhook asks telegram to send updates to webhookURL with secret token header
+func (bot *Bot) SetWebhook(webhookURL, secret string) error {
+	params := url.Values{}
+	params.Set("url", webhookURL)
+	params.Set("secret_token", secret)
+	params.Set("allowed_updates", `["message","callback_query"]`)
+	resp, err := bot.api.MakeRequest("setWebhook", params)
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return fmt.Errorf("setWebhook failed: %s", resp.Description)
+	}
+	return nil
+}
+
+/*
+StartWebhook registers webhook and serves updates on listenAddr,
+path of webhook url is used as handler pattern
+*/
+func (bot *Bot) StartWebhook(webhookURL, secret, listenAddr string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return fmt.Errorf("secret token required in webhook mode")
+	}
+	if err := bot.SetWebhook(webhookURL, secret); err != nil {
+		return err
+	}
+	pattern := u.Path
+	if pattern == "" {
+		pattern = "/"
+	}
+	mux := http.NewServeMux()
+	mux.Handle(pattern, WebhookHandler(secret, bot.HandleUpdate))
+	log.Info().Fields(map[string]interface{}{
+		"addr": listenAddr,
+		"path": pattern,
+	}).Msg("Listening for telegram webhook")
+	return http.ListenAndServe(listenAddr, mux)
+}
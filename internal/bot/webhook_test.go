@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// fakeTelegram is local telegram bot api server recording called methods
+type fakeTelegram struct {
+	mu     sync.Mutex
+	server *httptest.Server
+	calls  map[string]url.Values
+}
+
+func newFakeTelegram(t *testing.T) *fakeTelegram {
+	f := &fakeTelegram{calls: make(map[string]url.Values)}
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		f.mu.Lock()
+		f.calls[method] = r.PostForm
+		f.mu.Unlock()
+		switch method {
+		case "getMe":
+			w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"p2phub","username":"p2phub_bot"}}`))
+		default:
+			w.Write([]byte(`{"ok":true,"result":true}`))
+		}
+	}))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// RoundTrip sends requests to api.telegram.org to fake server
+func (f *fakeTelegram) RoundTrip(r *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(f.server.URL)
+	r.URL.Scheme = u.Scheme
+	r.URL.Host = u.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func (f *fakeTelegram) call(method string) (url.Values, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	params, ok := f.calls[method]
+	return params, ok
+}
+
+func TestSetWebhook(t *testing.T) {
+	fake := newFakeTelegram(t)
+	api, err := tgbotapi.NewBotAPIWithClient("token", &http.Client{Transport: fake})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	bot := &Bot{api: api}
+
+	if err := bot.SetWebhook("https://p2phub.example/telegram/webhook", "s3cret"); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	params, ok := fake.call("setWebhook")
+	if !ok {
+		t.Fatal("setWebhook not called")
+	}
+	if params.Get("url") != "https://p2phub.example/telegram/webhook" || params.Get("secret_token") != "s3cret" {
+		t.Errorf("unexpected setWebhook params %v", params)
+	}
+}
+
+func TestWebhookHandler(t *testing.T) {
+	var handled []int
+	handler := WebhookHandler("s3cret", func(u tgbotapi.Update) error {
+		handled = append(handled, u.UpdateID)
+		if u.UpdateID == 8 {
+			return fmt.Errorf("chat 42 is locked")
+		}
+		return nil
+	})
+	body := `{"update_id":7,"message":{"message_id":1,"chat":{"id":42},"text":"/start"}}`
+	locked := `{"update_id":8,"message":{"message_id":2,"chat":{"id":42},"text":"/start"}}`
+
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		body   string
+		status int
+	}{
+		{"no secret", http.MethodPost, "", body, http.StatusUnauthorized},
+		{"wrong secret", http.MethodPost, "guess", body, http.StatusUnauthorized},
+		{"get", http.MethodGet, "s3cret", "", http.StatusMethodNotAllowed},
+		{"invalid body", http.MethodPost, "s3cret", "{", http.StatusBadRequest},
+		{"update", http.MethodPost, "s3cret", body, http.StatusOK},
+		{"not taken", http.MethodPost, "s3cret", locked, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/telegram/webhook", strings.NewReader(tt.body))
+		if tt.secret != "" {
+			req.Header.Set(secretHeader, tt.secret)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%s: expected status %d, got %d", tt.name, tt.status, rec.Code)
+		}
+	}
+	if len(handled) != 2 || handled[0] != 7 || handled[1] != 8 {
+		t.Errorf("expected only updates 7 and 8 handled, got %v", handled)
+	}
+}
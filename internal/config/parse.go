@@ -29,10 +29,41 @@ type Config struct {
 	Telegram struct {
 		APIkey     string `yaml:"api-key"`
 		InviteLink string `yaml:"bot-link"`
+		// Seconds without answer after which bot conversation expires
+		ConversationTimeout int `yaml:"conversation-timeout"`
+		// Mode is polling(default) or webhook
+		Mode string `yaml:"mode"`
+		// Public url telegram sends updates to in webhook mode
+		WebhookURL string `yaml:"webhook-url"`
+		// SecretToken is sent by telegram in every webhook request
+		SecretToken string `yaml:"secret-token"`
+		// ListenAddr of webhook listener
+		ListenAddr string `yaml:"listen-addr"`
 	}
 	Exchange struct {
-		MaxRetries int `yaml:"max-retries"`
-		RetryDelay int `yaml:"retry-delay"`
+		MaxRetries int      `yaml:"max-retries"`
+		RetryDelay int      `yaml:"retry-delay"`
+		Enabled    []string `yaml:"enabled"`
+		// Fees in percents per exchange, used by arbitrage scanner
+		Fees map[string]float64 `yaml:"fees"`
+	}
+	// Email notifications for users without telegram
+	Email struct {
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		From     string `yaml:"from"`
+		// DigestInterval is minimal number of minutes between emails to one user
+		DigestInterval int `yaml:"digest-interval"`
+		// UnsubscribeURL is public unsubscribe endpoint, token is added as query parameter
+		UnsubscribeURL string `yaml:"unsubscribe-url"`
+	}
+	// Observer polling intervals in seconds
+	Observer struct {
+		Tick         int `yaml:"tick"`
+		FreeInterval int `yaml:"free-interval"`
+		PaidInterval int `yaml:"paid-interval"`
 	}
 	Website struct {
 		Port        string `yaml:"port"`
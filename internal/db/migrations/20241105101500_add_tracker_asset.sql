@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trackers ADD COLUMN asset varchar(10) NOT NULL DEFAULT 'USDT';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trackers DROP COLUMN asset;
+-- +goose StatementEnd
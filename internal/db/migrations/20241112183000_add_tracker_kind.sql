@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trackers
+    ADD COLUMN kind varchar(16) NOT NULL DEFAULT 'outbid',
+    ADD COLUMN threshold decimal NOT NULL DEFAULT 0,
+    ADD COLUMN direction varchar(5) NOT NULL DEFAULT '',
+    ADD COLUMN compare_exchange varchar NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trackers
+    DROP COLUMN kind,
+    DROP COLUMN threshold,
+    DROP COLUMN direction,
+    DROP COLUMN compare_exchange;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE market_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    exchange varchar NOT NULL,
+    asset varchar(10) NOT NULL,
+    currency varchar(3) NOT NULL,
+    side varchar(4) NOT NULL,
+    best_price decimal NOT NULL,
+    depth decimal NOT NULL,
+    ads_count INT NOT NULL,
+    top_ads jsonb NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX market_snapshots_book_idx ON market_snapshots (exchange, asset, currency, side, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE market_snapshots;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE arbitrages (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    asset varchar(10) NOT NULL DEFAULT 'USDT',
+    currency varchar(3) NOT NULL,
+    buy_exchange varchar NOT NULL,
+    sell_exchange varchar NOT NULL,
+    threshold decimal NOT NULL DEFAULT 0,
+    payment_methods text[] NOT NULL DEFAULT '{}',
+    notify boolean DEFAULT true,
+    waiting_update boolean DEFAULT false,
+    last_spread decimal NOT NULL DEFAULT 0,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE arbitrages;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- polling interval in seconds, 0 means the fastest interval allowed by subscription
+ALTER TABLE trackers
+    ADD COLUMN check_interval integer NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trackers
+    DROP COLUMN check_interval;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trackers
+    ADD COLUMN min_gap decimal NOT NULL DEFAULT 0,
+    ADD COLUMN gap_is_percent boolean NOT NULL DEFAULT false,
+    ADD COLUMN min_quantity decimal NOT NULL DEFAULT 0,
+    ADD COLUMN min_completion_rate decimal NOT NULL DEFAULT 0,
+    ADD COLUMN min_orders integer NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trackers
+    DROP COLUMN min_gap,
+    DROP COLUMN gap_is_percent,
+    DROP COLUMN min_quantity,
+    DROP COLUMN min_completion_rate,
+    DROP COLUMN min_orders;
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- mode is ignore(never counts as outbidder) or only(only these count as outbidders)
+CREATE TABLE tracker_nicknames (
+    tracker_id INT NOT NULL,
+    nickname varchar(64) NOT NULL,
+    mode varchar(8) NOT NULL DEFAULT 'ignore',
+    UNIQUE (tracker_id, nickname, mode),
+    CONSTRAINT fk_tracker
+        FOREIGN KEY (tracker_id)
+        REFERENCES trackers(id)
+        ON DELETE CASCADE
+);
+
+-- team nicknames never count as outbidders on any tracker of user
+CREATE TABLE team_nicknames (
+    user_id INT NOT NULL,
+    nickname varchar(64) NOT NULL,
+    UNIQUE (user_id, nickname),
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE tracker_nicknames;
+DROP TABLE team_nicknames;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+-- status is pending(published), sent, failed or limited(free plan limit reached)
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    tracker_id INT,
+    kind varchar(16) NOT NULL DEFAULT 'outbid',
+    exchange varchar NOT NULL,
+    asset varchar(10) NOT NULL,
+    currency varchar(3) NOT NULL,
+    side varchar(4) NOT NULL DEFAULT '',
+    competitor varchar(64) NOT NULL DEFAULT '',
+    price decimal NOT NULL DEFAULT 0,
+    payment_methods text[] NOT NULL DEFAULT '{}',
+    status varchar(16) NOT NULL DEFAULT 'pending',
+    message_id BIGINT,
+    error text NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_tracker
+        FOREIGN KEY (tracker_id)
+        REFERENCES trackers(id)
+        ON DELETE SET NULL
+);
+CREATE INDEX notifications_user_idx ON notifications (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notifications;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- kind is telegram, discord or slack, target is chat id or incoming webhook url
+CREATE TABLE notification_channels (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    kind varchar(16) NOT NULL,
+    target text NOT NULL,
+    name varchar(64) NOT NULL DEFAULT '',
+    enabled boolean NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE,
+    UNIQUE (user_id, kind, target)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notification_channels;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhooks (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    url text NOT NULL,
+    secret varchar(64) NOT NULL,
+    description varchar(128) NOT NULL DEFAULT '',
+    enabled boolean NOT NULL DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+-- events which were not delivered after all retries
+CREATE TABLE webhook_dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INT NOT NULL,
+    event_id varchar(64) NOT NULL,
+    event varchar(16) NOT NULL,
+    payload jsonb NOT NULL,
+    attempts INT NOT NULL,
+    error text NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_webhook
+        FOREIGN KEY (webhook_id)
+        REFERENCES webhooks(id)
+        ON DELETE CASCADE
+);
+CREATE INDEX webhook_dead_letters_webhook_idx ON webhook_dead_letters (webhook_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_dead_letters;
+DROP TABLE webhooks;
+-- +goose StatementEnd
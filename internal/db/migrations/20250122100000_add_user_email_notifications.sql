@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- false after user clicked unsubscribe link in notification email
+ALTER TABLE users ADD COLUMN email_notifications boolean NOT NULL DEFAULT true;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN email_notifications;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- quiet hours are HH:MM in user's timezone, empty when disabled,
+-- max_per_hour 0 means unlimited, empty channels means all channels
+CREATE TABLE user_settings (
+    user_id INT PRIMARY KEY,
+    timezone varchar(64) NOT NULL DEFAULT 'UTC',
+    quiet_start varchar(5) NOT NULL DEFAULT '',
+    quiet_end varchar(5) NOT NULL DEFAULT '',
+    max_per_hour INT NOT NULL DEFAULT 0,
+    channels text[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY (user_id)
+        REFERENCES users(id)
+        ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_settings;
+-- +goose StatementEnd
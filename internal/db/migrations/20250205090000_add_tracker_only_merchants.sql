@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE trackers
+    ADD COLUMN only_merchants boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE trackers
+    DROP COLUMN only_merchants;
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- telegram channels are linked from the chat since this migration,
+-- chats entered by id are kept only if they are connected telegram of the user
+DELETE FROM notification_channels c
+    USING users u
+    WHERE c.user_id = u.id
+      AND c.kind = 'telegram'
+      AND c.target IS DISTINCT FROM u.chat_id::text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- deleted channels can't be restored
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+package models
+
+import "github.com/lib/pq"
+
+// Arbitrage compares best SELL advertisement on BuyExchange(where user buys asset)
+// with best BUY advertisement on SellExchange(where user sells asset)
+type Arbitrage struct {
+	ID            int64          `db:"id" json:"id"`
+	UserID        int            `db:"user_id" json:"-"`
+	Asset         string         `db:"asset" json:"asset"`
+	Currency      string         `db:"currency" json:"currency"`
+	BuyExchange   string         `db:"buy_exchange" json:"buy_exchange"`
+	SellExchange  string         `db:"sell_exchange" json:"sell_exchange"`
+	Threshold     float64        `db:"threshold" json:"threshold"`
+	Payment       pq.StringArray `db:"payment_methods" json:"payment_methods"`
+	Notify        bool           `db:"notify" json:"notify"`
+	WaitingUpdate bool           `db:"waiting_update" json:"waiting_update"`
+	LastSpread    float64        `db:"last_spread" json:"last_spread"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// BookKey identifies advertisement book on exchange,
+// trackers with the same key are checked against one GetAds response
+type BookKey struct {
+	Exchange string `db:"exchange"`
+	Asset    string `db:"asset"`
+	Currency string `db:"currency"`
+	Side     string `db:"side"`
+}
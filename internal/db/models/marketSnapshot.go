@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MarketSnapshot is a state of advertisement book at observer tick
+type MarketSnapshot struct {
+	ID int64 `db:"id" json:"id"`
+	BookKey
+	BestPrice float64         `db:"best_price" json:"best_price"`
+	Depth     float64         `db:"depth" json:"depth"`
+	AdsCount  int             `db:"ads_count" json:"ads_count"`
+	TopAds    json.RawMessage `db:"top_ads" json:"top_ads"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
+
+// TopAd is an advertisement stored in snapshot
+type TopAd struct {
+	Name     string   `json:"name"`
+	Price    float64  `json:"price"`
+	Quantity float64  `json:"quantity"`
+	Payment  []string `json:"payment_methods"`
+}
+
+// MarketCandle is OHLC bucket of best prices
+type MarketCandle struct {
+	Bucket  time.Time `db:"bucket" json:"time"`
+	Open    float64   `db:"open" json:"open"`
+	High    float64   `db:"high" json:"high"`
+	Low     float64   `db:"low" json:"low"`
+	Close   float64   `db:"close" json:"close"`
+	Depth   float64   `db:"depth" json:"depth"`
+	Samples int       `db:"samples" json:"samples"`
+}
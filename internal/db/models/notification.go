@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification delivery statuses
+const (
+	// NotificationPending is published and waits for delivery
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+	// NotificationLimited is not published because free plan limit is reached
+	NotificationLimited = "limited"
+	// NotificationQueued is held during user's quiet hours and sent in summary
+	NotificationQueued = "queued"
+	// NotificationThrottled is not published because user's hourly limit is reached
+	NotificationThrottled = "throttled"
+)
+
+// Notification is a delivery log record of notification sent to user
+type Notification struct {
+	ID         int64          `db:"id" json:"id"`
+	UserID     int            `db:"user_id" json:"-"`
+	TrackerID  *int64         `db:"tracker_id" json:"tracker_id"`
+	Kind       string         `db:"kind" json:"kind"`
+	Exchange   string         `db:"exchange" json:"exchange"`
+	Asset      string         `db:"asset" json:"asset"`
+	Currency   string         `db:"currency" json:"currency"`
+	Side       string         `db:"side" json:"side"`
+	Competitor string         `db:"competitor" json:"competitor"`
+	Price      float64        `db:"price" json:"price"`
+	Payment    pq.StringArray `db:"payment_methods" json:"payment_methods"`
+	Status     string         `db:"status" json:"status"`
+	MessageID  *int64         `db:"message_id" json:"message_id"`
+	Error      string         `db:"error" json:"error,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	SentAt     *time.Time     `db:"sent_at" json:"sent_at"`
+}
+
+// NotificationFilter filters notification history, zero fields are ignored
+type NotificationFilter struct {
+	TrackerID int64
+	Kind      string
+	Status    string
+	Exchange  string
+	From      time.Time
+	To        time.Time
+	Limit     int
+	Offset    int
+}
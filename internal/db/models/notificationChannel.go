@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Notification channel kinds
+const (
+	// ChannelTelegram target is telegram chat id, linked by /start with code sent from chat
+	ChannelTelegram = "telegram"
+	// ChannelDiscord target is discord webhook url
+	ChannelDiscord = "discord"
+	// ChannelSlack target is slack incoming webhook url
+	ChannelSlack = "slack"
+)
+
+// NotificationChannel is a destination where user's notifications are delivered
+// in addition to connected telegram account
+type NotificationChannel struct {
+	ID        int64     `db:"id" json:"id"`
+	UserID    int       `db:"user_id" json:"-"`
+	Kind      string    `db:"kind" json:"kind"`
+	Target    string    `db:"target" json:"target"`
+	Name      string    `db:"name" json:"name"`
+	Enabled   bool      `db:"enabled" json:"enabled"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// OutbidRules decide which competitor advertisements count as outbid,
+// zero rules count every advertisement with different price
+type OutbidRules struct {
+	// MinGap is the smallest price difference counted as outbid
+	MinGap float64 `db:"min_gap" json:"min_gap"`
+	// GapIsPercent makes MinGap percents of tracker price
+	GapIsPercent bool `db:"gap_is_percent" json:"gap_is_percent"`
+	// MinQuantity of asset available in competitor advertisement
+	MinQuantity float64 `db:"min_quantity" json:"min_quantity"`
+	// MinCompletionRate of competitor in percents
+	MinCompletionRate float64 `db:"min_completion_rate" json:"min_completion_rate"`
+	// MinOrders is the smallest number of competitor's recent orders
+	MinOrders int `db:"min_orders" json:"min_orders"`
+	// OnlyMerchants makes advertisements of verified merchants the only ones which count
+	OnlyMerchants bool `db:"only_merchants" json:"only_merchants"`
+}
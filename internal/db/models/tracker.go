@@ -1,15 +1,54 @@
 package models
 
+// Tracker kinds
+const (
+	// TrackerKindOutbid notifies when user's advertisement is outbidded
+	TrackerKindOutbid = "outbid"
+	// TrackerKindPrice notifies when best price crosses threshold
+	TrackerKindPrice = "price"
+	// TrackerKindSpread notifies when spread between exchanges is above threshold(%)
+	TrackerKindSpread = "spread"
+)
+
+// Nickname list modes
+const (
+	// NicknameModeIgnore nicknames never count as outbidders
+	NicknameModeIgnore = "ignore"
+	// NicknameModeOnly nicknames are the only ones counted as outbidders
+	NicknameModeOnly = "only"
+)
+
 type Tracker struct {
-	ID            int64            `db:"id"`
-	UserID        int              `db:"user_id"`
-	Exchange      string           `db:"exchange"`
-	Currency      string           `db:"currency"`
-	Side          string           `db:"side"`
-	Username      string           `db:"username"`
-	Notify        bool             `db:"notify"`
-	Price         float64          `db:"price"`
-	WaitingUpdate bool             `db:"waiting_update"`
-	IsAggregated  bool             `db:"is_aggregated"`
-	Payment       []*PaymentMethod `db:"-"`
+	ID              int64   `db:"id"`
+	UserID          int     `db:"user_id"`
+	Exchange        string  `db:"exchange"`
+	Asset           string  `db:"asset"`
+	Currency        string  `db:"currency"`
+	Side            string  `db:"side"`
+	Username        string  `db:"username"`
+	Notify          bool    `db:"notify"`
+	Price           float64 `db:"price"`
+	WaitingUpdate   bool    `db:"waiting_update"`
+	IsAggregated    bool    `db:"is_aggregated"`
+	Kind            string  `db:"kind"`
+	Threshold       float64 `db:"threshold"`
+	Direction       string  `db:"direction"`
+	CompareExchange string  `db:"compare_exchange"`
+	// Interval between checks in seconds, 0 means the fastest allowed by subscription
+	Interval int `db:"check_interval"`
+	OutbidRules
+	Payment         []*PaymentMethod `db:"-"`
+	IgnoreNicknames []string         `db:"-"`
+	OnlyNicknames   []string         `db:"-"`
+	// TeamNicknames of tracker owner, loaded only for observer
+	TeamNicknames []string `db:"-"`
+}
+
+// TrackerSchedule is tracker book with polling settings, used by observer scheduler
+type TrackerSchedule struct {
+	ID int `db:"id"`
+	BookKey
+	Interval int `db:"check_interval"`
+	// Paid is true if tracker owner has active subscription
+	Paid bool `db:"paid"`
 }
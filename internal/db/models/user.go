@@ -4,4 +4,6 @@ type User struct {
 	ID     int     `db:"id"`
 	ChatID *int64  `db:"chat_id"`
 	Email  *string `db:"email"`
+	// EmailNotifications is false if user unsubscribed from notification emails
+	EmailNotifications bool `db:"email_notifications"`
 }
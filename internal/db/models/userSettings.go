@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification channel kinds which are not notification_channels records,
+// used in preferred channels of user settings
+const (
+	// ChannelEmail is digest email of users without telegram
+	ChannelEmail = "email"
+	// ChannelWebhook is user's outgoing webhooks
+	ChannelWebhook = "webhook"
+)
+
+// UserSettings are notification preferences of user
+type UserSettings struct {
+	UserID int `db:"user_id" json:"-"`
+	// Timezone is IANA name of user's timezone
+	Timezone string `db:"timezone" json:"timezone"`
+	// Quiet hours are HH:MM in user's timezone, notifications are held
+	// during quiet hours and sent as a summary when they end
+	QuietStart string `db:"quiet_start" json:"quiet_start"`
+	QuietEnd   string `db:"quiet_end" json:"quiet_end"`
+	// MaxPerHour limits notifications sent in an hour, 0 is unlimited
+	MaxPerHour int `db:"max_per_hour" json:"max_per_hour"`
+	// Channels user wants to receive notifications in, all channels if empty
+	Channels  pq.StringArray `db:"channels" json:"channels"`
+	UpdatedAt time.Time      `db:"updated_at" json:"updated_at"`
+}
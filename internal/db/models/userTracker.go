@@ -1,16 +1,25 @@
 package models
 
 type UserTracker struct {
-	ID            int64            `db:"tracker_id" json:"id"`
-	Exchange      string           `db:"exchange" json:"exchange"`
-	Currency      string           `db:"currency" json:"currency"`
-	Side          string           `db:"side" json:"side"`
-	Notify        bool             `db:"notify" json:"notify"`
-	Payment       []*PaymentMethod `db:"-" json:"payment_methods"`
-	Price         float64          `db:"price" json:"price"`
-	UserID        int              `db:"user_id" json:"-"`
-	ChatID        *int64           `db:"chat_id" json:"tg_chat_id"`
-	WaitingUpdate bool             `db:"waiting_update" json:"waiting_update"`
-	IsAggregated  bool             `db:"is_aggregated" json:"is_aggregated"`
-	Username      string           `db:"username" json:"username"`
+	ID              int64            `db:"tracker_id" json:"id"`
+	Exchange        string           `db:"exchange" json:"exchange"`
+	Asset           string           `db:"asset" json:"asset"`
+	Currency        string           `db:"currency" json:"currency"`
+	Side            string           `db:"side" json:"side"`
+	Notify          bool             `db:"notify" json:"notify"`
+	Payment         []*PaymentMethod `db:"-" json:"payment_methods"`
+	IgnoreNicknames []string         `db:"-" json:"ignore_nicknames"`
+	OnlyNicknames   []string         `db:"-" json:"only_nicknames"`
+	Price           float64          `db:"price" json:"price"`
+	UserID          int              `db:"user_id" json:"-"`
+	ChatID          *int64           `db:"chat_id" json:"tg_chat_id"`
+	WaitingUpdate   bool             `db:"waiting_update" json:"waiting_update"`
+	IsAggregated    bool             `db:"is_aggregated" json:"is_aggregated"`
+	Username        string           `db:"username" json:"username"`
+	Kind            string           `db:"kind" json:"kind"`
+	Threshold       float64          `db:"threshold" json:"threshold"`
+	Direction       string           `db:"direction" json:"direction"`
+	CompareExchange string           `db:"compare_exchange" json:"compare_exchange"`
+	Interval        int              `db:"check_interval" json:"interval"`
+	OutbidRules
 }
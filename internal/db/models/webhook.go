@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook is user's https endpoint receiving signed notification events
+type Webhook struct {
+	ID     int64  `db:"id" json:"id"`
+	UserID int    `db:"user_id" json:"-"`
+	URL    string `db:"url" json:"url"`
+	// Secret signs event payloads, shown only once on creation
+	Secret      string    `db:"secret" json:"-"`
+	Description string    `db:"description" json:"description"`
+	Enabled     bool      `db:"enabled" json:"enabled"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// WebhookDeadLetter is event which was not delivered to webhook after all retries
+type WebhookDeadLetter struct {
+	ID        int64           `db:"id" json:"id"`
+	WebhookID int64           `db:"webhook_id" json:"webhook_id"`
+	EventID   string          `db:"event_id" json:"event_id"`
+	Event     string          `db:"event" json:"event"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	Attempts  int             `db:"attempts" json:"attempts"`
+	Error     string          `db:"error" json:"error"`
+	CreatedAt time.Time       `db:"created_at" json:"created_at"`
+}
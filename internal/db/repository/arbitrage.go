@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ArbitrageRepository struct {
+	db *sqlx.DB
+}
+
+func NewArbitrageRepository(db *sqlx.DB) *ArbitrageRepository {
+	return &ArbitrageRepository{db}
+}
+
+func (repo *ArbitrageRepository) Save(a *models.Arbitrage) error {
+	if a == nil {
+		return fmt.Errorf("arbitrage is nil")
+	}
+
+	if a.ID == 0 {
+		query := `INSERT INTO arbitrages (user_id, asset, currency, buy_exchange, sell_exchange, threshold,
+            payment_methods, notify)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+            RETURNING id`
+		err := repo.db.QueryRow(query, a.UserID, a.Asset, a.Currency, a.BuyExchange, a.SellExchange,
+			a.Threshold, a.Payment, a.Notify).Scan(&a.ID)
+		if err != nil {
+			return fmt.Errorf("error creating new arbitrage : %v", err)
+		}
+		return nil
+	}
+
+	query := `UPDATE arbitrages SET asset = $1, currency = $2, buy_exchange = $3, sell_exchange = $4,
+        threshold = $5, payment_methods = $6, notify = $7, waiting_update = $8, last_spread = $9
+        WHERE id = $10`
+	_, err := repo.db.Exec(query, a.Asset, a.Currency, a.BuyExchange, a.SellExchange,
+		a.Threshold, a.Payment, a.Notify, a.WaitingUpdate, a.LastSpread, a.ID)
+	if err != nil {
+		return fmt.Errorf("error updating arbitrage : %v", err)
+	}
+	return nil
+}
+
+func (repo *ArbitrageRepository) GetByID(id int) (*models.Arbitrage, error) {
+	a := &models.Arbitrage{}
+	err := repo.db.Get(a, `SELECT * FROM arbitrages WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (repo *ArbitrageRepository) GetByUserID(id int) ([]*models.Arbitrage, error) {
+	out := make([]*models.Arbitrage, 0)
+	err := repo.db.Select(&out, `SELECT * FROM arbitrages WHERE user_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (repo *ArbitrageRepository) GetAll() ([]*models.Arbitrage, error) {
+	out := make([]*models.Arbitrage, 0)
+	err := repo.db.Select(&out, `SELECT * FROM arbitrages ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (repo *ArbitrageRepository) Delete(id int) (int64, error) {
+	result, err := repo.db.Exec(`DELETE FROM arbitrages WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -217,10 +217,10 @@ func TestMain(m *testing.M) {
 //    t.Logf("Outbided flag updated")
 //}
 
-func TestGetIdsByCurrency(t *testing.T) {
-	ids, err := trackerRepo.GetIdsByCurrency("binance")
+func TestGetSchedules(t *testing.T) {
+	schedules, err := trackerRepo.GetSchedules()
 	if err != nil {
-		t.Fatalf("error getting ids: %v", err)
+		t.Fatalf("error getting schedules: %v", err)
 	}
-	fmt.Println("IDs: ", ids)
+	fmt.Println("Schedules: ", schedules)
 }
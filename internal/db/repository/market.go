@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MarketRepository struct {
+	db *sqlx.DB
+}
+
+func NewMarketRepository(db *sqlx.DB) *MarketRepository {
+	return &MarketRepository{db}
+}
+
+func (repo *MarketRepository) SaveSnapshot(s *models.MarketSnapshot) error {
+	if s == nil {
+		return fmt.Errorf("snapshot is nil")
+	}
+	query := `INSERT INTO market_snapshots (exchange, asset, currency, side, best_price, depth, ads_count, top_ads)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, created_at`
+	err := repo.db.QueryRow(query, s.Exchange, s.Asset, s.Currency, s.Side,
+		s.BestPrice, s.Depth, s.AdsCount, s.TopAds).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving market snapshot : %v", err)
+	}
+	return nil
+}
+
+// GetHistory returns OHLC buckets of best price for book between from and to
+func (repo *MarketRepository) GetHistory(key models.BookKey, from, to time.Time, interval time.Duration) ([]*models.MarketCandle, error) {
+	out := make([]*models.MarketCandle, 0)
+	query := `SELECT date_bin($5::interval, created_at, TIMESTAMP '2000-01-01') AS bucket,
+            (array_agg(best_price ORDER BY created_at))[1] AS open,
+            MAX(best_price) AS high,
+            MIN(best_price) AS low,
+            (array_agg(best_price ORDER BY created_at DESC))[1] AS close,
+            AVG(depth) AS depth,
+            COUNT(*) AS samples
+        FROM market_snapshots
+        WHERE exchange = $1 AND asset = $2 AND currency = $3 AND side = $4
+            AND created_at >= $6 AND created_at < $7
+        GROUP BY bucket ORDER BY bucket`
+	err := repo.db.Select(&out, query, key.Exchange, key.Asset, key.Currency, key.Side,
+		fmt.Sprintf("%d seconds", int64(interval.Seconds())), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error getting market history : %v", err)
+	}
+	return out, nil
+}
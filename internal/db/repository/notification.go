@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db}
+}
+
+func (repo *NotificationRepository) Save(n *models.Notification) error {
+	if n == nil {
+		return fmt.Errorf("notification is nil")
+	}
+	query := `INSERT INTO notifications (user_id, tracker_id, kind, exchange, asset, currency, side,
+            competitor, price, payment_methods, status)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+            RETURNING id, created_at`
+	err := repo.db.QueryRow(query, n.UserID, n.TrackerID, n.Kind, n.Exchange, n.Asset, n.Currency, n.Side,
+		n.Competitor, n.Price, n.Payment, n.Status).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating new notification : %v", err)
+	}
+	return nil
+}
+
+// UpdateStatus sets delivery status, message id is stored only for sent notifications(0 for no message id)
+func (repo *NotificationRepository) UpdateStatus(id int64, status string, messageID int64, errText string) error {
+	query := `UPDATE notifications SET status = $1,
+            message_id = CASE WHEN $1 = 'sent' THEN NULLIF($2::bigint, 0) ELSE message_id END,
+            sent_at = CASE WHEN $1 = 'sent' THEN CURRENT_TIMESTAMP ELSE sent_at END,
+            error = $3
+            WHERE id = $4`
+	_, err := repo.db.Exec(query, status, messageID, errText, id)
+	return err
+}
+
+// GetByUserID returns page of user's notifications, newest first, and total count matching filter
+func (repo *NotificationRepository) GetByUserID(userID int, f models.NotificationFilter) ([]*models.Notification, int, error) {
+	conds := []string{"user_id = $1"}
+	args := []any{userID}
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if f.TrackerID != 0 {
+		add("tracker_id = $%d", f.TrackerID)
+	}
+	if f.Kind != "" {
+		add("kind = $%d", f.Kind)
+	}
+	if f.Status != "" {
+		add("status = $%d", f.Status)
+	}
+	if f.Exchange != "" {
+		add("exchange = $%d", f.Exchange)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	where := strings.Join(conds, " AND ")
+
+	var total int
+	if err := repo.db.Get(&total, `SELECT COUNT(*) FROM notifications WHERE `+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]*models.Notification, 0)
+	query := fmt.Sprintf(`SELECT * FROM notifications WHERE %s ORDER BY created_at DESC, id DESC
+            LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	if err := repo.db.Select(&out, query, append(args, f.Limit, f.Offset)...); err != nil {
+		return nil, 0, err
+	}
+	return out, total, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ChannelRepository struct {
+	db *sqlx.DB
+}
+
+func NewChannelRepository(db *sqlx.DB) *ChannelRepository {
+	return &ChannelRepository{db}
+}
+
+func (repo *ChannelRepository) Save(ch *models.NotificationChannel) error {
+	if ch == nil {
+		return fmt.Errorf("channel is nil")
+	}
+
+	if ch.ID == 0 {
+		query := `INSERT INTO notification_channels (user_id, kind, target, name, enabled)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, created_at`
+		err := repo.db.QueryRow(query, ch.UserID, ch.Kind, ch.Target, ch.Name, ch.Enabled).
+			Scan(&ch.ID, &ch.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("error creating new channel : %v", err)
+		}
+		return nil
+	}
+
+	query := `UPDATE notification_channels SET target = $1, name = $2, enabled = $3 WHERE id = $4`
+	_, err := repo.db.Exec(query, ch.Target, ch.Name, ch.Enabled, ch.ID)
+	if err != nil {
+		return fmt.Errorf("error updating channel : %v", err)
+	}
+	return nil
+}
+
+func (repo *ChannelRepository) GetByID(id int) (*models.NotificationChannel, error) {
+	ch := &models.NotificationChannel{}
+	err := repo.db.Get(ch, `SELECT * FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (repo *ChannelRepository) GetByUserID(id int) ([]*models.NotificationChannel, error) {
+	out := make([]*models.NotificationChannel, 0)
+	err := repo.db.Select(&out, `SELECT * FROM notification_channels WHERE user_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetEnabled returns channels of user which receive notifications
+func (repo *ChannelRepository) GetEnabled(userID int) ([]*models.NotificationChannel, error) {
+	out := make([]*models.NotificationChannel, 0)
+	err := repo.db.Select(&out, `SELECT * FROM notification_channels
+        WHERE user_id = $1 AND enabled ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (repo *ChannelRepository) Delete(id int) (int64, error) {
+	result, err := repo.db.Exec(`DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
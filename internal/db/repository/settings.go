@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SettingsRepository struct {
+	db *sqlx.DB
+}
+
+func NewSettingsRepository(db *sqlx.DB) *SettingsRepository {
+	return &SettingsRepository{db}
+}
+
+// Save creates or replaces settings of user
+func (repo *SettingsRepository) Save(s *models.UserSettings) error {
+	if s == nil {
+		return fmt.Errorf("settings are nil")
+	}
+	query := `INSERT INTO user_settings (user_id, timezone, quiet_start, quiet_end, max_per_hour, channels)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone,
+            quiet_start = EXCLUDED.quiet_start, quiet_end = EXCLUDED.quiet_end,
+            max_per_hour = EXCLUDED.max_per_hour, channels = EXCLUDED.channels,
+            updated_at = CURRENT_TIMESTAMP
+        RETURNING updated_at`
+	err := repo.db.QueryRow(query, s.UserID, s.Timezone, s.QuietStart, s.QuietEnd, s.MaxPerHour, s.Channels).
+		Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("error saving settings : %v", err)
+	}
+	return nil
+}
+
+// GetByUserID returns settings of user, sql.ErrNoRows if user has not saved them
+func (repo *SettingsRepository) GetByUserID(userID int) (*models.UserSettings, error) {
+	s := &models.UserSettings{}
+	err := repo.db.Get(s, `SELECT * FROM user_settings WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
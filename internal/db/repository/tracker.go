@@ -5,7 +5,6 @@ import (
 	"p2pbot/internal/db/models"
 
 	"github.com/jmoiron/sqlx"
-	"github.com/lib/pq"
 )
 
 type TrackerRepository struct {
@@ -22,6 +21,12 @@ func (repo *TrackerRepository) UpdateWaitingUpdate(id int64, flag bool) error {
 	return err
 }
 
+// UpdateNotify changes only notify flag, so it doesn't race with observer saving tracker
+func (repo *TrackerRepository) UpdateNotify(id int64, notify bool) error {
+	_, err := repo.db.Exec(`UPDATE trackers SET notify = $1 WHERE id = $2`, notify, id)
+	return err
+}
+
 func (repo *TrackerRepository) Save(tracker *models.Tracker) error {
 	if tracker == nil {
 		return fmt.Errorf("tracker is nil")
@@ -33,12 +38,17 @@ func (repo *TrackerRepository) Save(tracker *models.Tracker) error {
 	}
 
 	if tracker.ID == 0 {
-		query := `INSERT INTO trackers (user_id, exchange, currency, side, username, notify, price, is_aggregated)
-            VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		query := `INSERT INTO trackers (user_id, exchange, asset, currency, side, username, notify, price, is_aggregated,
+            kind, threshold, direction, compare_exchange, check_interval,
+            min_gap, gap_is_percent, min_quantity, min_completion_rate, min_orders, only_merchants)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
             RETURNING id`
-		err := tx.QueryRow(query, tracker.UserID, tracker.Exchange,
+		err := tx.QueryRow(query, tracker.UserID, tracker.Exchange, tracker.Asset,
 			tracker.Currency, tracker.Side,
-			tracker.Username, tracker.Notify, tracker.Price, tracker.IsAggregated).Scan(&tracker.ID)
+			tracker.Username, tracker.Notify, tracker.Price, tracker.IsAggregated,
+			tracker.Kind, tracker.Threshold, tracker.Direction, tracker.CompareExchange,
+			tracker.Interval, tracker.MinGap, tracker.GapIsPercent, tracker.MinQuantity,
+			tracker.MinCompletionRate, tracker.MinOrders, tracker.OnlyMerchants).Scan(&tracker.ID)
 
 		if err != nil {
 			tx.Rollback()
@@ -46,10 +56,15 @@ func (repo *TrackerRepository) Save(tracker *models.Tracker) error {
 		}
 	} else {
 		query := `UPDATE trackers SET exchange = $1, currency = $2, side = $3, username = $4, notify = $5, price = $6,
-            is_aggregated = $7, waiting_update = $8 WHERE id = $9`
+            is_aggregated = $7, waiting_update = $8, asset = $9, kind = $10, threshold = $11, direction = $12,
+            compare_exchange = $13, check_interval = $14, min_gap = $15, gap_is_percent = $16,
+            min_quantity = $17, min_completion_rate = $18, min_orders = $19, only_merchants = $20 WHERE id = $21`
 		_, err = tx.Exec(query, tracker.Exchange, tracker.Currency,
 			tracker.Side, tracker.Username, tracker.Notify,
-			tracker.Price, tracker.IsAggregated, tracker.WaitingUpdate, tracker.ID)
+			tracker.Price, tracker.IsAggregated, tracker.WaitingUpdate, tracker.Asset,
+			tracker.Kind, tracker.Threshold, tracker.Direction, tracker.CompareExchange,
+			tracker.Interval, tracker.MinGap, tracker.GapIsPercent, tracker.MinQuantity,
+			tracker.MinCompletionRate, tracker.MinOrders, tracker.OnlyMerchants, tracker.ID)
 		if err != nil {
 			tx.Rollback()
 			return err
@@ -75,6 +90,29 @@ func (repo *TrackerRepository) Save(tracker *models.Tracker) error {
 		}
 	}
 
+	// Replace nickname lists
+	_, err = tx.Exec(`DELETE FROM tracker_nicknames WHERE tracker_id = $1`, tracker.ID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	query = `INSERT INTO tracker_nicknames (tracker_id, nickname, mode)
+                VALUES ($1, $2, $3)
+                ON CONFLICT DO NOTHING`
+	lists := map[string][]string{
+		models.NicknameModeIgnore: tracker.IgnoreNicknames,
+		models.NicknameModeOnly:   tracker.OnlyNicknames,
+	}
+	for mode, nicknames := range lists {
+		for _, nickname := range nicknames {
+			_, err = tx.Exec(query, tracker.ID, nickname, mode)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
 	return tx.Commit()
 }
 
@@ -88,10 +126,64 @@ func (repo *TrackerRepository) GetMethodsForTracker(trackerId int64) ([]*models.
 	return out, nil
 }
 
+// GetNicknamesForTracker returns ignored and only nicknames of tracker
+func (repo *TrackerRepository) GetNicknamesForTracker(trackerId int64) (ignore, only []string, err error) {
+	var rows []struct {
+		Nickname string `db:"nickname"`
+		Mode     string `db:"mode"`
+	}
+	err = repo.db.Select(&rows, `SELECT nickname, mode FROM tracker_nicknames
+            WHERE tracker_id = $1 ORDER BY nickname`, trackerId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting nicknames: %s", err)
+	}
+	ignore, only = make([]string, 0), make([]string, 0)
+	for _, row := range rows {
+		if row.Mode == models.NicknameModeOnly {
+			only = append(only, row.Nickname)
+		} else {
+			ignore = append(ignore, row.Nickname)
+		}
+	}
+	return ignore, only, nil
+}
+
+// GetTeamNicknames returns nicknames of user's team accounts
+func (repo *TrackerRepository) GetTeamNicknames(userID int) ([]string, error) {
+	out := make([]string, 0)
+	err := repo.db.Select(&out, `SELECT nickname FROM team_nicknames WHERE user_id = $1 ORDER BY nickname`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting team nicknames: %s", err)
+	}
+	return out, nil
+}
+
+// SetTeamNicknames replaces nicknames of user's team accounts
+func (repo *TrackerRepository) SetTeamNicknames(userID int, nicknames []string) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM team_nicknames WHERE user_id = $1`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, nickname := range nicknames {
+		_, err := tx.Exec(`INSERT INTO team_nicknames (user_id, nickname) VALUES ($1, $2)
+                ON CONFLICT DO NOTHING`, userID, nickname)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
 func (repo *TrackerRepository) GetAllTrackers() ([]*models.UserTracker, error) {
 	var trackers []*models.UserTracker
-	query := `SELECT t.id as tracker_id, t.exchange, t.currency, t.side, t.username,
-        t.notify, t.waiting_update, t.is_aggregated, t.price, u.id, u.chat_id as user_id 
+	query := `SELECT t.id as tracker_id, t.exchange, t.asset, t.currency, t.side, t.username,
+        t.notify, t.waiting_update, t.is_aggregated, t.price, t.kind, t.threshold, t.direction, t.compare_exchange, t.check_interval,
+        t.min_gap, t.gap_is_percent, t.min_quantity, t.min_completion_rate, t.min_orders, t.only_merchants, u.id, u.chat_id as user_id 
         FROM trackers t JOIN public.users u on t.user_id = u.id`
 	err := repo.db.Select(&trackers, query)
 	if err != nil {
@@ -103,14 +195,19 @@ func (repo *TrackerRepository) GetAllTrackers() ([]*models.UserTracker, error) {
 		if err != nil {
 			return nil, err
 		}
+		tracker.IgnoreNicknames, tracker.OnlyNicknames, err = repo.GetNicknamesForTracker(tracker.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return trackers, nil
 }
 
 func (repo *TrackerRepository) GetTrackersByUserId(id int) ([]*models.UserTracker, error) {
 	var trackers []*models.UserTracker
-	query := `SELECT t.id as tracker_id, t.exchange, t.currency, t.side, t.username,
-        t.notify, t.waiting_update, t.is_aggregated, t.price, u.id as user_id, u.chat_id
+	query := `SELECT t.id as tracker_id, t.exchange, t.asset, t.currency, t.side, t.username,
+        t.notify, t.waiting_update, t.is_aggregated, t.price, t.kind, t.threshold, t.direction, t.compare_exchange, t.check_interval,
+        t.min_gap, t.gap_is_percent, t.min_quantity, t.min_completion_rate, t.min_orders, t.only_merchants, u.id as user_id, u.chat_id
         FROM trackers t JOIN public.users u on t.user_id = u.id WHERE u.id = $1`
 	err := repo.db.Select(&trackers, query, id)
 	if err != nil {
@@ -122,6 +219,10 @@ func (repo *TrackerRepository) GetTrackersByUserId(id int) ([]*models.UserTracke
 		if err != nil {
 			return nil, err
 		}
+		tracker.IgnoreNicknames, tracker.OnlyNicknames, err = repo.GetNicknamesForTracker(tracker.ID)
+		if err != nil {
+			return nil, err
+		}
 	}
 	return trackers, nil
 }
@@ -145,6 +246,15 @@ func (repo *TrackerRepository) GetTrackerById(id int) (*models.Tracker, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Get nicknames
+	tracker.IgnoreNicknames, tracker.OnlyNicknames, err = repo.GetNicknamesForTracker(tracker.ID)
+	if err != nil {
+		return nil, err
+	}
+	tracker.TeamNicknames, err = repo.GetTeamNicknames(tracker.UserID)
+	if err != nil {
+		return nil, err
+	}
 
 	return tracker, nil
 }
@@ -158,6 +268,25 @@ func (repo *TrackerRepository) UpdatePaymentMethodOutbided(trackerId int64, name
 	return nil
 }
 
+// ResetOutbid clears waiting update flag of tracker and outbidded flags of its payment methods
+func (repo *TrackerRepository) ResetOutbid(trackerId int64) error {
+	tx, err := repo.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE trackers SET waiting_update = false WHERE id = $1`, trackerId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(`UPDATE methods SET outbidded = false WHERE tracker_id = $1`, trackerId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func (repo *TrackerRepository) DeleteTracker(id int) (int64, error) {
 	query := `DELETE FROM trackers WHERE id = $1`
 	result, err := repo.db.Exec(query, id)
@@ -168,30 +297,15 @@ func (repo *TrackerRepository) DeleteTracker(id int) (int64, error) {
 	return result.RowsAffected()
 }
 
-// methods specifc to observer
-func (repo *TrackerRepository) GetIdsByCurrency(exchange string) (map[string][]int, error) {
-	var Result []struct {
-		Key string `db:"key"`
-		Ids []byte `db:"ids"`
-	}
-
-	err := repo.db.Select(&Result, `SELECT CONCAT(currency, side) as key , array_agg(id::int) as ids
-            FROM trackers WHERE exchange = $1 GROUP BY currency, side`, exchange)
+// GetSchedules returns books and polling settings of all trackers,
+// paid is true if tracker owner has active subscription
+func (repo *TrackerRepository) GetSchedules() ([]models.TrackerSchedule, error) {
+	out := make([]models.TrackerSchedule, 0)
+	err := repo.db.Select(&out, `SELECT t.id, t.exchange, t.asset, t.currency, t.side, t.check_interval,
+            EXISTS(SELECT 1 FROM subscription s WHERE s.user_id = t.user_id AND s.valid_until > now()) AS paid
+            FROM trackers t`)
 	if err != nil {
 		return nil, err
 	}
-	// Psql returns array of bytes, we need to convert it to int
-	out := make(map[string][]int)
-	for _, r := range Result {
-		var ids []int64
-		if err := pq.Array(&ids).Scan(r.Ids); err != nil {
-			return nil, err
-		}
-
-		out[r.Key] = make([]int, len(ids))
-		for i, id := range ids {
-			out[r.Key][i] = int(id)
-		}
-	}
 	return out, nil
 }
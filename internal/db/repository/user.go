@@ -75,6 +75,12 @@ func (repo *UserRepository) Update(user *models.User) error {
 	return err
 }
 
+// SetEmailNotifications enables or disables notification emails of user
+func (repo *UserRepository) SetEmailNotifications(id int, enabled bool) error {
+	_, err := repo.db.Exec(`UPDATE users SET email_notifications = $1 WHERE id = $2`, enabled, id)
+	return err
+}
+
 func (repo *UserRepository) GetByChatID(chatID int64) (*models.User, error) {
 	user := &models.User{}
 
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+func (repo *WebhookRepository) Save(w *models.Webhook) error {
+	if w == nil {
+		return fmt.Errorf("webhook is nil")
+	}
+
+	if w.ID == 0 {
+		query := `INSERT INTO webhooks (user_id, url, secret, description, enabled)
+            VALUES ($1, $2, $3, $4, $5)
+            RETURNING id, created_at`
+		err := repo.db.QueryRow(query, w.UserID, w.URL, w.Secret, w.Description, w.Enabled).
+			Scan(&w.ID, &w.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("error creating new webhook : %v", err)
+		}
+		return nil
+	}
+
+	query := `UPDATE webhooks SET url = $1, description = $2, enabled = $3 WHERE id = $4`
+	_, err := repo.db.Exec(query, w.URL, w.Description, w.Enabled, w.ID)
+	if err != nil {
+		return fmt.Errorf("error updating webhook : %v", err)
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) GetByID(id int) (*models.Webhook, error) {
+	w := &models.Webhook{}
+	err := repo.db.Get(w, `SELECT * FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (repo *WebhookRepository) GetByUserID(id int) ([]*models.Webhook, error) {
+	out := make([]*models.Webhook, 0)
+	err := repo.db.Select(&out, `SELECT * FROM webhooks WHERE user_id = $1 ORDER BY id`, id)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetEnabled returns webhooks of user which receive events
+func (repo *WebhookRepository) GetEnabled(userID int) ([]*models.Webhook, error) {
+	out := make([]*models.Webhook, 0)
+	err := repo.db.Select(&out, `SELECT * FROM webhooks WHERE user_id = $1 AND enabled ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (repo *WebhookRepository) Delete(id int) (int64, error) {
+	result, err := repo.db.Exec(`DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (repo *WebhookRepository) SaveDeadLetter(d *models.WebhookDeadLetter) error {
+	query := `INSERT INTO webhook_dead_letters (webhook_id, event_id, event, payload, attempts, error)
+            VALUES ($1, $2, $3, $4, $5, $6)
+            RETURNING id, created_at`
+	err := repo.db.QueryRow(query, d.WebhookID, d.EventID, d.Event, []byte(d.Payload), d.Attempts, d.Error).
+		Scan(&d.ID, &d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error creating new dead letter : %v", err)
+	}
+	return nil
+}
+
+// GetDeadLetters returns latest undelivered events of webhook, newest first
+func (repo *WebhookRepository) GetDeadLetters(webhookID int64, limit int) ([]*models.WebhookDeadLetter, error) {
+	out := make([]*models.WebhookDeadLetter, 0)
+	err := repo.db.Select(&out, `SELECT * FROM webhook_dead_letters WHERE webhook_id = $1
+        ORDER BY created_at DESC, id DESC LIMIT $2`, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
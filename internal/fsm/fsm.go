@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"p2pbot/internal/db/models"
+	"time"
 )
 
 type State int
@@ -27,21 +28,31 @@ const (
 	SideGiven
 	AdvertisementFound
 	AdvertisementNotFound
+	// Cancel returns conversation to Welcome from any state
+	Cancel
+	// Expired returns conversation to Welcome when user didn't answer in time
+	Expired
 )
 
 type Action func(args ...any)
 
 type FSM struct {
-	current     map[int64]State
+	store       Store
+	timeout     time.Duration
 	transitions map[State]map[Event]State
 	actions     map[State]map[Event]Action
+	now         func() time.Time
 }
 
-func New() *FSM {
+// New creates FSM which keeps conversations in store,
+// conversation expires if there was no transition during timeout(0 disables expiry)
+func New(store Store, timeout time.Duration) *FSM {
 	fsm := &FSM{
-		current:     make(map[int64]State),
+		store:       store,
+		timeout:     timeout,
 		transitions: make(map[State]map[Event]State),
 		actions:     make(map[State]map[Event]Action),
+		now:         time.Now,
 	}
 
 	fsm.transitions[Welcome] = map[Event]State{
@@ -112,7 +123,17 @@ func New() *FSM {
 	}
 	fsm.actions[AwaitingExchangeUsername] = map[Event]Action{
 		UsernameGiven: func(args ...any) {
+			u, ok := args[0].(string)
+			if !ok {
+				log.Fatal("arg[0] must be a string")
+			}
+
+			t, ok := args[1].(*models.Tracker)
+			if !ok {
+				log.Fatal("arg[1] must be a models.Tracker")
+			}
 
+			t.Username = u
 		},
 	}
 	fsm.actions[AwaitingSide] = map[Event]Action{
@@ -123,23 +144,63 @@ func New() *FSM {
 
 		},
 	}
+	// Conversation can be cancelled in any state and expires in any state except Welcome
+	for _, state := range []State{Welcome, AwaitingExchange, AwaitingСurrency, AwaitingExchangeUsername, AwaitingSide} {
+		if fsm.transitions[state] == nil {
+			fsm.transitions[state] = make(map[Event]State)
+		}
+		fsm.transitions[state][Cancel] = Welcome
+		fsm.actions[state][Cancel] = func(args ...any) {}
+		if state != Welcome {
+			fsm.transitions[state][Expired] = Welcome
+			fsm.actions[state][Expired] = func(args ...any) {}
+		}
+	}
 	return fsm
 }
 
 func (fsm *FSM) Transition(chatID int64, event Event, args ...any) (State, error) {
+	c, err := fsm.store.Load(chatID)
+	if err != nil {
+		return -1, err
+	}
 
-	if newState, ok := fsm.transitions[fsm.current[chatID]][event]; ok {
-		if action, ok := fsm.actions[fsm.current[chatID]][event]; ok {
+	if newState, ok := fsm.transitions[c.State][event]; ok {
+		if action, ok := fsm.actions[c.State][event]; ok {
 			action(args...)
-			fsm.current[chatID] = newState
+			if err := fsm.store.Save(chatID, Conversation{State: newState, UpdatedAt: fsm.now()}); err != nil {
+				return -1, err
+			}
 			return newState, nil
 		} else {
-			log.Fatalf("Fsm invalid, action not found for state %d, event %d", fsm.current[chatID], event)
+			log.Fatalf("Fsm invalid, action not found for state %d, event %d", c.State, event)
 		}
 	}
-	return -1, fmt.Errorf("invalid transition for state %d, event %d", fsm.current[chatID], event)
+	return -1, fmt.Errorf("invalid transition for state %d, event %d", c.State, event)
 }
 
+// GetState returns current state of chat, Welcome if state can't be loaded
 func (fsm *FSM) GetState(id int64) State {
-	return fsm.current[id]
+	c, err := fsm.store.Load(id)
+	if err != nil {
+		log.Printf("Error loading conversation of %d: %v", id, err)
+		return Welcome
+	}
+	return c.State
+}
+
+// Expire moves conversation to Welcome with Expired event
+// if there was no transition during timeout, return true if conversation expired
+func (fsm *FSM) Expire(chatID int64) (bool, error) {
+	c, err := fsm.store.Load(chatID)
+	if err != nil {
+		return false, err
+	}
+	if fsm.timeout <= 0 || c.State == Welcome || fsm.now().Sub(c.UpdatedAt) < fsm.timeout {
+		return false, nil
+	}
+	if _, err := fsm.Transition(chatID, Expired); err != nil {
+		return false, err
+	}
+	return true, nil
 }
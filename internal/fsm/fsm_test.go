@@ -0,0 +1,97 @@
+package fsm
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+	"time"
+)
+
+func TestNewTrackerConversation(t *testing.T) {
+	f := New(NewMemoryStore(), time.Minute)
+	tracker := &models.Tracker{}
+	var chatID int64 = 42
+
+	steps := []struct {
+		event Event
+		args  []any
+		want  State
+	}{
+		{NewTracker, nil, AwaitingExchange},
+		{ExchangeFound, []any{int64(7), "binance", tracker}, AwaitingСurrency},
+		{CurrencyGiven, []any{"EUR", tracker}, AwaitingExchangeUsername},
+		{UsernameGiven, []any{"anton_p2p", tracker}, AwaitingSide},
+		{AdvertisementFound, nil, Welcome},
+	}
+	for _, step := range steps {
+		state, err := f.Transition(chatID, step.event, step.args...)
+		if err != nil || state != step.want {
+			t.Fatalf("event %d: got state %d, %v, want %d", step.event, state, err, step.want)
+		}
+	}
+	if tracker.UserID != 7 || tracker.Exchange != "binance" || tracker.Currency != "EUR" || tracker.Username != "anton_p2p" {
+		t.Errorf("tracker not filled: %+v", tracker)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	f := New(NewMemoryStore(), time.Minute)
+	var chatID int64 = 42
+
+	if _, err := f.Transition(chatID, CurrencyGiven, "EUR", &models.Tracker{}); err == nil {
+		t.Error("expected invalid transition")
+	}
+	f.Transition(chatID, NewTracker)
+	if state, err := f.Transition(chatID, Cancel); err != nil || state != Welcome {
+		t.Errorf("expected Welcome after cancel, got %d, %v", state, err)
+	}
+	// Cancel without conversation is allowed
+	if _, err := f.Transition(chatID, Cancel); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+}
+
+func TestExpire(t *testing.T) {
+	f := New(NewMemoryStore(), time.Minute)
+	now := time.Date(2024, 12, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	var chatID int64 = 42
+
+	// Nothing to expire without conversation
+	if expired, err := f.Expire(chatID); err != nil || expired {
+		t.Errorf("expected no expiry, got %v, %v", expired, err)
+	}
+
+	f.Transition(chatID, NewTracker)
+	now = now.Add(30 * time.Second)
+	if expired, _ := f.Expire(chatID); expired || f.GetState(chatID) != AwaitingExchange {
+		t.Error("conversation expired before timeout")
+	}
+
+	// Answer moves timeout
+	f.Transition(chatID, ExchangeFound, int64(7), "binance", &models.Tracker{})
+	now = now.Add(50 * time.Second)
+	if expired, _ := f.Expire(chatID); expired {
+		t.Error("conversation expired before timeout")
+	}
+
+	now = now.Add(time.Minute)
+	if expired, err := f.Expire(chatID); err != nil || !expired {
+		t.Fatalf("expected expiry, got %v, %v", expired, err)
+	}
+	if state := f.GetState(chatID); state != Welcome {
+		t.Errorf("expected Welcome after expiry, got %d", state)
+	}
+}
+
+func TestStateShared(t *testing.T) {
+	// Replicas share conversations through store
+	store := NewMemoryStore()
+	a := New(store, time.Minute)
+	b := New(store, time.Minute)
+	var chatID int64 = 42
+
+	a.Transition(chatID, NewTracker)
+	if state, err := b.Transition(chatID, ExchangeFound, int64(7), "binance", &models.Tracker{}); err != nil || state != AwaitingСurrency {
+		t.Errorf("expected AwaitingСurrency, got %d, %v", state, err)
+	}
+}
@@ -0,0 +1,87 @@
+package fsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"p2pbot/internal/rediscl"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Conversation is state of chat with time of the last transition
+type Conversation struct {
+	State     State     `json:"state"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store keeps conversations of chats,
+// Load returns zero Conversation(Welcome) for unknown chat
+type Store interface {
+	Load(chatID int64) (Conversation, error)
+	Save(chatID int64, c Conversation) error
+}
+
+// RedisStore keeps conversations in redis, so they survive restarts
+// and are shared between bot replicas
+type RedisStore struct {
+	ttl time.Duration
+}
+
+// NewRedisStore creates store, conversations are removed from redis after ttl
+func NewRedisStore(ttl time.Duration) *RedisStore {
+	return &RedisStore{ttl: ttl}
+}
+
+func conversationKey(chatID int64) string {
+	return fmt.Sprintf("fsm:%d", chatID)
+}
+
+func (s *RedisStore) Load(chatID int64) (Conversation, error) {
+	var c Conversation
+	data, err := rediscl.RDB.Client.Get(rediscl.RDB.Ctx, conversationKey(chatID)).Bytes()
+	if err == redis.Nil {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+func (s *RedisStore) Save(chatID int64, c Conversation) error {
+	// Nothing to keep for finished conversation
+	if c.State == Welcome {
+		return rediscl.RDB.Client.Del(rediscl.RDB.Ctx, conversationKey(chatID)).Err()
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return rediscl.RDB.Client.Set(rediscl.RDB.Ctx, conversationKey(chatID), data, s.ttl).Err()
+}
+
+// MemoryStore keeps conversations in memory, used in tests and single instance setups
+type MemoryStore struct {
+	mu            sync.Mutex
+	conversations map[int64]Conversation
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{conversations: make(map[int64]Conversation)}
+}
+
+func (s *MemoryStore) Load(chatID int64) (Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conversations[chatID], nil
+}
+
+func (s *MemoryStore) Save(chatID int64, c Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conversations[chatID] = c
+	return nil
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/requests"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetArbitrages returns all arbitrages of user
+func (contr *Controller) GetArbitrages(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	arbitrages, err := contr.arbitrageService.GetByUserID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+	}).Msg("Arbitrages requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":    fmt.Sprintf("Arbitrages for user %s", email),
+		"arbitrages": arbitrages,
+	})
+}
+
+func (contr *Controller) GetArbitrage(c echo.Context) error {
+	arb, err := contr.userArbitrage(c)
+	if arb == nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":   "Arbitrage found",
+		"arbitrage": arb,
+	})
+}
+
+func (contr *Controller) CreateArbitrage(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	arbReq := new(requests.ArbitrageRequest)
+	if err := c.Bind(arbReq); err != nil {
+		return err
+	}
+
+	arb := &models.Arbitrage{
+		UserID:       u.ID,
+		Asset:        arbReq.Asset,
+		Currency:     arbReq.Currency,
+		BuyExchange:  arbReq.BuyExchange,
+		SellExchange: arbReq.SellExchange,
+		Notify:       true,
+		Payment:      make([]string, 0),
+	}
+	if arbReq.Threshold != nil {
+		arb.Threshold = *arbReq.Threshold
+	}
+	if arbReq.Notify != nil {
+		arb.Notify = *arbReq.Notify
+	}
+	if arbReq.Payment != nil {
+		arb.Payment = arbReq.Payment
+	}
+
+	if err := contr.arbitrageService.Validate(arb); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+
+	if err := contr.arbitrageService.Save(arb); err != nil {
+		return err
+	}
+	log.Debug().Fields(map[string]interface{}{
+		"arbitrage": arb,
+	}).Msg("Arbitrage created")
+
+	return c.JSON(http.StatusCreated, map[string]any{
+		"message":   "Arbitrage created",
+		"arbitrage": arb,
+	})
+}
+
+// UpdateArbitrage changes threshold, payment methods and notify flag of arbitrage
+func (contr *Controller) UpdateArbitrage(c echo.Context) error {
+	arb, err := contr.userArbitrage(c)
+	if arb == nil {
+		return err
+	}
+
+	arbReq := new(requests.ArbitrageRequest)
+	if err := c.Bind(arbReq); err != nil {
+		return err
+	}
+
+	if arbReq.Threshold != nil {
+		arb.Threshold = *arbReq.Threshold
+	}
+	if arbReq.Notify != nil {
+		arb.Notify = *arbReq.Notify
+	}
+	if arbReq.Payment != nil {
+		arb.Payment = arbReq.Payment
+	}
+
+	if err := contr.arbitrageService.Validate(arb); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+	if err := contr.arbitrageService.Save(arb); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":   "Arbitrage updated",
+		"arbitrage": arb,
+	})
+}
+
+func (contr *Controller) DeleteArbitrage(c echo.Context) error {
+	arb, err := contr.userArbitrage(c)
+	if arb == nil {
+		return err
+	}
+	if err := contr.arbitrageService.Delete(int(arb.ID)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":   "Arbitrage deleted",
+		"arbitrage": arb.ID,
+	})
+}
+
+// userArbitrage returns arbitrage from :id param if it belongs to user,
+// otherwise error response is written and returned arbitrage is nil
+func (contr *Controller) userArbitrage(c echo.Context) (*models.Arbitrage, error) {
+	return userResource(contr, c, "arbitrage", contr.arbitrageService.GetByID,
+		func(a *models.Arbitrage) int { return a.UserID })
+}
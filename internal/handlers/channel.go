@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/requests"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetChannels returns notification channels of user
+func (contr *Controller) GetChannels(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	channels, err := contr.channelService.GetByUserID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+	}).Msg("Channels requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  fmt.Sprintf("Channels for user %s", email),
+		"channels": channels,
+	})
+}
+
+/*
+CreateChannel links discord or slack webhook to user.
+
+Telegram channel is not created right away, link with one time code is returned
+and chat is linked when the link is opened in it
+*/
+func (contr *Controller) CreateChannel(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	chReq := new(requests.ChannelRequest)
+	if err := c.Bind(chReq); err != nil {
+		return err
+	}
+
+	ch := &models.NotificationChannel{
+		UserID:  u.ID,
+		Kind:    chReq.Kind,
+		Target:  chReq.Target,
+		Enabled: true,
+	}
+	if chReq.Name != nil {
+		ch.Name = *chReq.Name
+	}
+	if chReq.Enabled != nil {
+		ch.Enabled = *chReq.Enabled
+	}
+
+	// telegram chat is linked by the bot when link is opened in it
+	if ch.Kind == models.ChannelTelegram {
+		ch.Target = ""
+	}
+
+	if err := contr.channelService.Validate(ch); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+
+	if ch.Kind == models.ChannelTelegram {
+		code, err := contr.channelService.NewTelegramLink(ch)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusAccepted, map[string]any{
+			"message":    "Open link in telegram chat to connect it",
+			"link":       contr.TgLink + "?start=" + code,
+			"group_link": contr.TgLink + "?startgroup=" + code,
+		})
+	}
+
+	if err := contr.channelService.Save(ch); err != nil {
+		return err
+	}
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+		"kind":  ch.Kind,
+	}).Msg("Channel created")
+
+	return c.JSON(http.StatusCreated, map[string]any{
+		"message": "Channel created",
+		"channel": ch,
+	})
+}
+
+// UpdateChannel changes target, name and enabled flag of channel
+func (contr *Controller) UpdateChannel(c echo.Context) error {
+	ch, err := contr.userChannel(c)
+	if ch == nil {
+		return err
+	}
+
+	chReq := new(requests.ChannelRequest)
+	if err := c.Bind(chReq); err != nil {
+		return err
+	}
+
+	if chReq.Target != "" {
+		if ch.Kind == models.ChannelTelegram {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"invalid_param": "Telegram chat can't be changed, create new channel to link another chat",
+				},
+			})
+		}
+		ch.Target = chReq.Target
+	}
+	if chReq.Name != nil {
+		ch.Name = *chReq.Name
+	}
+	if chReq.Enabled != nil {
+		ch.Enabled = *chReq.Enabled
+	}
+
+	if err := contr.channelService.Validate(ch); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+	if err := contr.channelService.Save(ch); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Channel updated",
+		"channel": ch,
+	})
+}
+
+func (contr *Controller) DeleteChannel(c echo.Context) error {
+	ch, err := contr.userChannel(c)
+	if ch == nil {
+		return err
+	}
+	if err := contr.channelService.Delete(int(ch.ID)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Channel deleted",
+		"channel": ch.ID,
+	})
+}
+
+// userChannel returns channel from :id param if it belongs to user,
+// otherwise error response is written and returned channel is nil
+func (contr *Controller) userChannel(c echo.Context) (*models.NotificationChannel, error) {
+	return userResource(contr, c, "channel", contr.channelService.GetByID,
+		func(ch *models.NotificationChannel) int { return ch.UserID })
+}
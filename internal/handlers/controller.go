@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"p2pbot/internal/config"
+	"p2pbot/internal/notify"
+	"p2pbot/internal/orderbook"
+	"p2pbot/internal/rabbitmq"
 	"p2pbot/internal/services"
 )
 
@@ -9,6 +12,15 @@ type Controller struct {
 	userService          *services.UserService
 	trackerService       *services.TrackerService
 	subscriptionsService *services.SubscriptionService
+	marketService        *services.MarketService
+	arbitrageService     *services.ArbitrageService
+	notificationService  *services.NotificationService
+	channelService       *services.ChannelService
+	webhookService       *services.WebhookService
+	settingsService      *services.SettingsService
+	webhookDispatcher    *notify.WebhookDispatcher
+	events               *rabbitmq.RabbitMQ
+	orderBooks           *orderbook.Hub
 	exchanges            map[string]services.ExchangeI
 	JWTSecret            string
 	TgLink               string
@@ -19,6 +31,13 @@ type Controller struct {
 func NewController(userService *services.UserService,
 	trackerService *services.TrackerService,
 	subscriptionsService *services.SubscriptionService,
+	marketService *services.MarketService,
+	arbitrageService *services.ArbitrageService,
+	notificationService *services.NotificationService,
+	channelService *services.ChannelService,
+	webhookService *services.WebhookService,
+	settingsService *services.SettingsService,
+	events *rabbitmq.RabbitMQ,
 	exs map[string]services.ExchangeI,
 	cfg *config.Config) *Controller {
 
@@ -26,6 +45,15 @@ func NewController(userService *services.UserService,
 		userService,
 		trackerService,
 		subscriptionsService,
+		marketService,
+		arbitrageService,
+		notificationService,
+		channelService,
+		webhookService,
+		settingsService,
+		notify.NewWebhookDispatcher(webhookService),
+		events,
+		orderbook.NewHub(events),
 		exs,
 		cfg.Website.JWTSecret,
 		cfg.Telegram.InviteLink,
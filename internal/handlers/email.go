@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unsubscribePage asks to confirm unsubscribing, it posts the form to the same url with token
+const unsubscribePage = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Unsubscribe</title></head>
+<body><form method="post"><p>Stop receiving p2phub notification emails?</p>
+<button type="submit">Unsubscribe</button></form></body></html>`
+
+/*
+ConfirmUnsubscribe shows confirmation of unsubscribe link from email.
+
+Link scanners of mail services open links, so GET request doesn't change anything,
+unsubscribing happens on POST of the page or one-click unsubscribe of mail client
+*/
+func (contr *Controller) ConfirmUnsubscribe(c echo.Context) error {
+	if _, err := services.ParseUnsubscribeToken(contr.JWTSecret, c.QueryParam("token")); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Invalid unsubscribe link",
+			"errors": map[string]any{
+				"token": err.Error(),
+			},
+		})
+	}
+	return c.HTML(http.StatusOK, unsubscribePage)
+}
+
+// Unsubscribe disables notification emails of user from token of email link
+func (contr *Controller) Unsubscribe(c echo.Context) error {
+	userID, err := services.ParseUnsubscribeToken(contr.JWTSecret, c.QueryParam("token"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Invalid unsubscribe link",
+			"errors": map[string]any{
+				"token": err.Error(),
+			},
+		})
+	}
+	if err := contr.userService.SetEmailNotifications(userID, false); err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"user_id": userID,
+	}).Msg("Unsubscribed from emails")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "You will not receive notification emails anymore",
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// eventsPingInterval keeps idle event stream open through proxies
+const eventsPingInterval = 30 * time.Second
+
+// GetEvents streams tracker state transitions and notifications of user as server-sent events
+func (contr *Controller) GetEvents(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	msgs, closeSub, err := contr.events.Subscribe(services.EventsExchange, services.UserEventsKey(u.ID))
+	if err != nil {
+		return err
+	}
+	defer closeSub()
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+	}).Msg("Events stream opened")
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	// disable nginx response buffering
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	ping := time.NewTicker(eventsPingInterval)
+	defer ping.Stop()
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			if _, err := fmt.Fprint(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case msg, ok := <-msgs:
+			if !ok {
+				return nil
+			}
+			// only type is needed, event is passed to browser as is
+			var e struct {
+				Type string `json:"type"`
+			}
+			if err := json.Unmarshal(msg.Body, &e); err != nil {
+				log.Error().Msg(err.Error())
+				continue
+			}
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", e.Type, msg.Body); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetMarketHistory returns OHLC buckets of best price for exchange book
+// query parameters: exchange, asset(USDT by default), currency, side,
+// from and to in RFC3339(last 24 hours by default), interval(1h by default)
+func (contr *Controller) GetMarketHistory(c echo.Context) error {
+	email := c.Get("email").(string)
+
+	key := models.BookKey{
+		Exchange: strings.ToLower(c.QueryParam("exchange")),
+		Asset:    services.NormalizeAsset(c.QueryParam("asset")),
+		Currency: strings.ToUpper(c.QueryParam("currency")),
+		Side:     strings.ToUpper(c.QueryParam("side")),
+	}
+	if _, ok := contr.exchanges[key.Exchange]; !ok {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "exchange not found",
+			"errors": map[string]any{
+				"exchange": fmt.Sprintf("%s not supported", key.Exchange),
+			},
+		})
+	}
+	if key.Currency == "" {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "currency not found",
+			"errors": map[string]any{
+				"currency": "query parameter not provided",
+			},
+		})
+	}
+	if key.Side != "BUY" && key.Side != "SELL" {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"side": "must be BUY/SELL",
+			},
+		})
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-24 * time.Hour)
+	interval := time.Hour
+	var err error
+	if p := c.QueryParam("from"); p != "" {
+		if from, err = time.Parse(time.RFC3339, p); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"from": "must be RFC3339 time",
+				},
+			})
+		}
+	}
+	if p := c.QueryParam("to"); p != "" {
+		if to, err = time.Parse(time.RFC3339, p); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"to": "must be RFC3339 time",
+				},
+			})
+		}
+	}
+	if p := c.QueryParam("interval"); p != "" {
+		if interval, err = services.ParseInterval(p); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"interval": err.Error(),
+				},
+			})
+		}
+	}
+
+	if err := services.ValidateHistoryQuery(from, to, interval); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+
+	candles, err := contr.marketService.GetHistory(key, from.UTC(), to.UTC(), interval)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email":    email,
+		"book":     key,
+		"interval": interval.String(),
+		"buckets":  len(candles),
+	}).Msg("Market history requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Market history",
+		"history": candles,
+	})
+}
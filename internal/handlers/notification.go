@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetNotifications returns user's notification history, newest first
+// query parameters: page(1 by default), limit(10 by default),
+// filters tracker_id, kind, status, exchange, from and to in RFC3339
+func (contr *Controller) GetNotifications(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	filter, err := parseNotificationFilter(c)
+	if err == nil {
+		err = services.ValidateNotificationFilter(filter)
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+
+	notifications, total, err := contr.notificationService.GetHistory(u.ID, filter)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email":  email,
+		"offset": filter.Offset,
+		"total":  total,
+	}).Msg("Notifications requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":       fmt.Sprintf("Notifications for user %s", email),
+		"notifications": notifications,
+		"total":         total,
+		"hasMore":       filter.Offset+len(notifications) < total,
+	})
+}
+
+func parseNotificationFilter(c echo.Context) (models.NotificationFilter, error) {
+	filter := models.NotificationFilter{
+		Kind:     strings.ToLower(c.QueryParam("kind")),
+		Status:   strings.ToLower(c.QueryParam("status")),
+		Exchange: strings.ToLower(c.QueryParam("exchange")),
+		Limit:    10,
+	}
+	page := 1
+	var err error
+	if p := c.QueryParam("page"); p != "" {
+		if page, err = strconv.Atoi(p); err != nil || page < 1 {
+			return filter, fmt.Errorf("page must be positive number")
+		}
+	}
+	if p := c.QueryParam("limit"); p != "" {
+		if filter.Limit, err = strconv.Atoi(p); err != nil {
+			return filter, fmt.Errorf("limit must be a number")
+		}
+	}
+	filter.Offset = (page - 1) * filter.Limit
+	if p := c.QueryParam("tracker_id"); p != "" {
+		if filter.TrackerID, err = strconv.ParseInt(p, 10, 64); err != nil {
+			return filter, fmt.Errorf("tracker_id must be a number")
+		}
+	}
+	if p := c.QueryParam("from"); p != "" {
+		if filter.From, err = time.Parse(time.RFC3339, p); err != nil {
+			return filter, fmt.Errorf("from must be RFC3339 time")
+		}
+	}
+	if p := c.QueryParam("to"); p != "" {
+		if filter.To, err = time.Parse(time.RFC3339, p); err != nil {
+			return filter, fmt.Errorf("to must be RFC3339 time")
+		}
+	}
+	return filter, nil
+}
+
+// GetNotificationSchema returns JSON schema of notification messages published to rabbitmq
+func (contr *Controller) GetNotificationSchema(c echo.Context) error {
+	return c.Blob(http.StatusOK, "application/schema+json", services.NotificationSchema)
+}
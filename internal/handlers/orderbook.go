@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+/*
+GetOrderBookFeed streams top of the advertisement book over websocket,
+first message is snapshot of the book, next ones are diffs between observer ticks.
+query parameters: exchange, asset(USDT by default), currency, side,
+user must have tracker on the book
+*/
+func (contr *Controller) GetOrderBookFeed(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	key := models.BookKey{
+		Exchange: strings.ToLower(c.QueryParam("exchange")),
+		Asset:    services.NormalizeAsset(c.QueryParam("asset")),
+		Currency: strings.ToUpper(c.QueryParam("currency")),
+		Side:     strings.ToUpper(c.QueryParam("side")),
+	}
+	if _, ok := contr.exchanges[key.Exchange]; !ok {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "exchange not found",
+			"errors": map[string]any{
+				"exchange": fmt.Sprintf("%s not supported", key.Exchange),
+			},
+		})
+	}
+
+	trackers, err := contr.trackerService.GetTrackersByUserId(u.ID)
+	if err != nil {
+		return err
+	}
+	tracked := false
+	for _, t := range trackers {
+		if strings.EqualFold(t.Exchange, key.Exchange) && strings.EqualFold(t.Asset, key.Asset) &&
+			strings.EqualFold(t.Currency, key.Currency) && strings.EqualFold(t.Side, key.Side) {
+			tracked = true
+			break
+		}
+	}
+	if !tracked {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "Book not found",
+			"errors": map[string]any{
+				"book": "no tracker on this book",
+			},
+		})
+	}
+
+	updates, unsubscribe, err := contr.orderBooks.Subscribe(key)
+	if err != nil {
+		return err
+	}
+	defer unsubscribe()
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+		"book":  key,
+	}).Msg("Order book feed opened")
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+		// client doesn't send messages, reading detects closed connection
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			var msg string
+			for websocket.Message.Receive(ws, &msg) == nil {
+			}
+		}()
+		for {
+			select {
+			case <-closed:
+				return
+			case msg, ok := <-updates:
+				if !ok {
+					return
+				}
+				if err := websocket.JSON.Send(ws, msg); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
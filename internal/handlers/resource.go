@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+/*
+userResource loads resource of :id path parameter and checks that it belongs to user of request.
+
+name is resource name used in error responses, owner returns id of resource user.
+nil resource is returned if error response was written or error occurred
+*/
+func userResource[T any](contr *Controller, c echo.Context, name string,
+	get func(id int) (*T, error), owner func(*T) int) (*T, error) {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return nil, c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Invalid " + name + " ID",
+			"errors": map[string]any{
+				name: "invalid ID",
+			},
+		})
+	}
+	res, err := get(id)
+	if err != nil {
+		return nil, c.JSON(http.StatusNotFound, map[string]any{
+			"message": strings.ToUpper(name[:1]) + name[1:] + " not found",
+			"errors": map[string]any{
+				name: "not found",
+			},
+		})
+	}
+	if owner(res) != u.ID {
+		return nil, c.JSON(http.StatusForbidden, map[string]any{
+			"message": "Forbidden",
+			"errors": map[string]any{
+				name: "not found",
+			},
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"database/sql"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/requests"
+	"p2pbot/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetSettings returns notification settings of user, defaults if user has not saved them
+func (contr *Controller) GetSettings(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	settings, err := contr.settingsService.Get(u.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  "Settings",
+		"settings": settings,
+	})
+}
+
+// UpdateSettings replaces quiet hours, hourly limit and preferred channels of user
+func (contr *Controller) UpdateSettings(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	sReq := new(requests.SettingsRequest)
+	if err := c.Bind(sReq); err != nil {
+		return err
+	}
+	settings := &models.UserSettings{
+		UserID:     u.ID,
+		Timezone:   sReq.Timezone,
+		QuietStart: sReq.QuietStart,
+		QuietEnd:   sReq.QuietEnd,
+		MaxPerHour: sReq.MaxPerHour,
+		Channels:   sReq.Channels,
+	}
+	if err := services.ValidateSettings(settings); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+	if err := contr.settingsService.Save(settings); err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+	}).Msg("Settings updated")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  "Settings updated",
+		"settings": settings,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"database/sql"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/requests"
+	"p2pbot/internal/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetTeam returns nicknames of user's team accounts
+func (contr *Controller) GetTeam(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	nicknames, err := contr.trackerService.GetTeamNicknames(u.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":   "Team nicknames",
+		"nicknames": nicknames,
+	})
+}
+
+// UpdateTeam replaces nicknames of user's team accounts,
+// they never count as outbidders on user's trackers
+func (contr *Controller) UpdateTeam(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	teamReq := new(requests.TeamRequest)
+	if err := c.Bind(teamReq); err != nil {
+		return err
+	}
+	nicknames, err := services.NormalizeNicknames(teamReq.Nicknames)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"nicknames": err.Error(),
+			},
+		})
+	}
+	if err := contr.trackerService.SetTeamNicknames(u.ID, nicknames); err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email":     email,
+		"nicknames": nicknames,
+	}).Msg("Team updated")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":   "Team updated",
+		"nicknames": nicknames,
+	})
+}
@@ -171,12 +171,26 @@ func (contr *Controller) CreateTracker(c echo.Context) error {
 	tracker := &models.Tracker{
 		UserID:   u.ID,
 		Exchange: trackerReq.Exchange,
+		Asset:    trackerReq.Asset,
 		Currency: trackerReq.Currency,
 		Side:     trackerReq.Side,
 		Username: trackerReq.Username,
 		Notify:   *trackerReq.Notify,
 		Payment:  make([]*models.PaymentMethod, 0),
+		// Market alert fields
+		Kind:            trackerReq.Kind,
+		Threshold:       trackerReq.Threshold,
+		Direction:       trackerReq.Direction,
+		CompareExchange: trackerReq.CompareExchange,
 	}
+	if trackerReq.Interval != nil {
+		tracker.Interval = *trackerReq.Interval
+	}
+	if trackerReq.OutbidRules != nil {
+		tracker.OutbidRules = *trackerReq.OutbidRules
+	}
+	tracker.IgnoreNicknames = trackerReq.IgnoreNicknames
+	tracker.OnlyNicknames = trackerReq.OnlyNicknames
 	// If no payments method provided in request, treat as aggregated tracker
 	if len(trackerReq.Payment) == 0 {
 		tracker.IsAggregated = true
@@ -207,7 +221,13 @@ func (contr *Controller) CreateTracker(c echo.Context) error {
 		})
 	}
 
-	ads, err := exchange.GetAdsByName(tracker.Currency,
+	// Market alerts don't track user's advertisement
+	if tracker.Kind != models.TrackerKindOutbid {
+		return contr.createAlertTracker(c, exchange, tracker, trackerReq.Payment)
+	}
+
+	ads, err := exchange.GetAdsByName(tracker.Asset,
+		tracker.Currency,
 		tracker.Side,
 		tracker.Username,
 		trackerReq.Payment)
@@ -231,7 +251,10 @@ func (contr *Controller) CreateTracker(c echo.Context) error {
 		return err
 	}
 	createdTrackers := make([]models.Tracker, 0)
+	// Tracked advertisements with advertiser stats
+	advertisements := make([]services.Advertisement, 0, len(ads))
 	for _, adv := range ads {
+		advertisements = append(advertisements, adv.ToAdvertisement())
 		// Set price
 		tracker.Price = adv.GetPrice()
 		// Set payment methods
@@ -275,9 +298,52 @@ func (contr *Controller) CreateTracker(c echo.Context) error {
 		tracker.ID = 0
 	}
 
+	return c.JSON(http.StatusCreated, map[string]any{
+		"message":        "Trackers created",
+		"trackers":       createdTrackers,
+		"advertisements": advertisements,
+	})
+}
+
+// createAlertTracker saves price/spread tracker,
+// payment methods are optional and filter ads on tracker exchange
+func (contr *Controller) createAlertTracker(c echo.Context,
+	exchange services.ExchangeI,
+	tracker *models.Tracker,
+	pmIds []string) error {
+
+	pMethods, err := exchange.GetCachedPaymentMethods(tracker.Currency)
+	if err != nil {
+		return err
+	}
+	pms := make([]*models.PaymentMethod, 0)
+	for _, p := range pmIds {
+		name, err := services.GetPMethodName(pMethods, p)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "payment method not found",
+				"errors": map[string]any{
+					"payment_method": fmt.Sprintf("%s not found", p),
+				},
+			})
+		}
+		pms = append(pms, &models.PaymentMethod{
+			Id:   p,
+			Name: name,
+		})
+	}
+	tracker.Payment = pms
+
+	if err := contr.trackerService.CreateTracker(tracker); err != nil {
+		return err
+	}
+	log.Debug().Fields(map[string]interface{}{
+		"tracker": tracker,
+	}).Msg("Alert tracker created")
+
 	return c.JSON(http.StatusCreated, map[string]any{
 		"message":  "Trackers created",
-		"trackers": createdTrackers,
+		"trackers": []models.Tracker{*tracker},
 	})
 }
 
@@ -378,6 +444,9 @@ func (contr *Controller) SetNotifyTracker(c echo.Context) error {
 	}
 
 	trackerReq := new(requests.TrackerRequest)
+	// Rules missing in request keep their current values
+	rules := tracker.OutbidRules
+	trackerReq.OutbidRules = &rules
 	if err := c.Bind(trackerReq); err != nil {
 		return err
 	}
@@ -386,6 +455,44 @@ func (contr *Controller) SetNotifyTracker(c echo.Context) error {
 	if trackerReq.Notify != nil {
 		tracker.Notify = *trackerReq.Notify
 	}
+	if trackerReq.Interval != nil {
+		if err := services.ValidateInterval(*trackerReq.Interval); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"interval": err.Error(),
+				},
+			})
+		}
+		tracker.Interval = *trackerReq.Interval
+	}
+	if trackerReq.OutbidRules != nil {
+		if err := services.ValidateOutbidRules(*trackerReq.OutbidRules); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"outbid_rules": err.Error(),
+				},
+			})
+		}
+		tracker.OutbidRules = *trackerReq.OutbidRules
+	}
+	if trackerReq.IgnoreNicknames != nil || trackerReq.OnlyNicknames != nil {
+		if trackerReq.IgnoreNicknames != nil {
+			tracker.IgnoreNicknames = trackerReq.IgnoreNicknames
+		}
+		if trackerReq.OnlyNicknames != nil {
+			tracker.OnlyNicknames = trackerReq.OnlyNicknames
+		}
+		if err := contr.trackerService.ValidateTracker(tracker, false); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]any{
+				"message": "Validation error",
+				"errors": map[string]any{
+					"nicknames": err.Error(),
+				},
+			})
+		}
+	}
 
 	err = contr.trackerService.CreateTracker(tracker)
 	if err != nil {
@@ -508,6 +615,7 @@ func (cont *Controller) GetExchanges(c echo.Context) error {
 	for k := range cont.exchanges {
 		out = append(out, k)
 	}
+	slices.Sort(out)
 
 	log.Info().Fields(map[string]interface{}{
 		"email":     email,
@@ -520,6 +628,20 @@ func (cont *Controller) GetExchanges(c echo.Context) error {
 	})
 }
 
+func (cont *Controller) GetAssets(c echo.Context) error {
+	email := c.Get("email").(string)
+
+	log.Info().Fields(map[string]interface{}{
+		"email":  email,
+		"assets": services.SupportedAssets,
+	}).Msg("Assets requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Assets",
+		"options": services.SupportedAssets,
+	})
+}
+
 func (cont *Controller) TestFunc(c echo.Context) error {
 	email := c.Get("email").(string)
 	return c.JSON(http.StatusOK, map[string]any{
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/notify"
+	"p2pbot/internal/requests"
+	"p2pbot/internal/services"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// deadLettersPage is number of dead letters returned for webhook
+const deadLettersPage = 50
+
+// GetWebhooks returns webhooks of user
+func (contr *Controller) GetWebhooks(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := contr.webhookService.GetByUserID(u.ID)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Fields(map[string]interface{}{
+		"email": email,
+	}).Msg("Webhooks requested")
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":  fmt.Sprintf("Webhooks for user %s", email),
+		"webhooks": webhooks,
+	})
+}
+
+// CreateWebhook registers https endpoint, signing secret is returned only in this response
+func (contr *Controller) CreateWebhook(c echo.Context) error {
+	email := c.Get("email").(string)
+	u, err := contr.userService.GetUserByEmail(email)
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]any{
+			"message": "User not found",
+			"errors": map[string]any{
+				"user": "not found",
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	whReq := new(requests.WebhookRequest)
+	if err := c.Bind(whReq); err != nil {
+		return err
+	}
+
+	w := &models.Webhook{
+		UserID:  u.ID,
+		URL:     whReq.URL,
+		Enabled: true,
+	}
+	if whReq.Description != nil {
+		w.Description = *whReq.Description
+	}
+	if whReq.Enabled != nil {
+		w.Enabled = *whReq.Enabled
+	}
+
+	if err := contr.webhookService.Validate(w); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+
+	if err := contr.webhookService.Save(w); err != nil {
+		return err
+	}
+	log.Info().Fields(map[string]interface{}{
+		"email":   email,
+		"webhook": w.ID,
+	}).Msg("Webhook created")
+
+	return c.JSON(http.StatusCreated, map[string]any{
+		"message": "Webhook created",
+		"webhook": w,
+		"secret":  w.Secret,
+	})
+}
+
+// UpdateWebhook changes url, description and enabled flag of webhook
+func (contr *Controller) UpdateWebhook(c echo.Context) error {
+	w, err := contr.userWebhook(c)
+	if w == nil {
+		return err
+	}
+
+	whReq := new(requests.WebhookRequest)
+	if err := c.Bind(whReq); err != nil {
+		return err
+	}
+
+	if whReq.URL != "" {
+		w.URL = whReq.URL
+	}
+	if whReq.Description != nil {
+		w.Description = *whReq.Description
+	}
+	if whReq.Enabled != nil {
+		w.Enabled = *whReq.Enabled
+	}
+
+	if err := contr.webhookService.Validate(w); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]any{
+			"message": "Validation error",
+			"errors": map[string]any{
+				"invalid_param": err.Error(),
+			},
+		})
+	}
+	if err := contr.webhookService.Save(w); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Webhook updated",
+		"webhook": w,
+	})
+}
+
+func (contr *Controller) DeleteWebhook(c echo.Context) error {
+	w, err := contr.userWebhook(c)
+	if w == nil {
+		return err
+	}
+	if err := contr.webhookService.Delete(int(w.ID)); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Webhook deleted",
+		"webhook": w.ID,
+	})
+}
+
+// TestWebhook posts signed test event to webhook once and returns delivery result
+func (contr *Controller) TestWebhook(c echo.Context) error {
+	w, err := contr.userWebhook(c)
+	if w == nil {
+		return err
+	}
+
+	event := services.WebhookEvent{
+		ID:        services.NewEventID(),
+		Type:      services.WebhookEventTest,
+		CreatedAt: time.Now().UTC(),
+		Data: services.EventData{
+			Exchange:       "binance",
+			Asset:          "USDT",
+			Currency:       "EUR",
+			Side:           "SELL",
+			Advertiser:     "p2phub_test",
+			Price:          1,
+			PaymentMethods: []string{"Revolut"},
+		},
+	}
+	if err := contr.webhookDispatcher.DeliverOnce(w, event); err != nil {
+		// only status code is returned, response of webhook host is never shown
+		reason := "Webhook is unreachable"
+		var status *notify.StatusError
+		if errors.As(err, &status) {
+			reason = fmt.Sprintf("Webhook responded with status %d", status.StatusCode)
+		}
+		return c.JSON(http.StatusBadGateway, map[string]any{
+			"message": "Test event not delivered",
+			"errors": map[string]any{
+				"webhook": reason,
+			},
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "Test event delivered",
+		"event":   event,
+	})
+}
+
+// GetWebhookDeadLetters returns latest events not delivered to webhook
+func (contr *Controller) GetWebhookDeadLetters(c echo.Context) error {
+	w, err := contr.userWebhook(c)
+	if w == nil {
+		return err
+	}
+	deadLetters, err := contr.webhookService.GetDeadLetters(w.ID, deadLettersPage)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, map[string]any{
+		"message":      fmt.Sprintf("Dead letters of webhook %d", w.ID),
+		"dead_letters": deadLetters,
+	})
+}
+
+// userWebhook returns webhook from :id param if it belongs to user,
+// otherwise error response is written and returned webhook is nil
+func (contr *Controller) userWebhook(c echo.Context) (*models.Webhook, error) {
+	return userResource(contr, c, "webhook", contr.webhookService.GetByID,
+		func(w *models.Webhook) int { return w.UserID })
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"net/http"
+	"p2pbot/internal/services"
+)
+
+// discordColor is color of embed side line
+const discordColor = 0xF0B90B
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordEmbed struct {
+	Title  string              `json:"title"`
+	Color  int                 `json:"color"`
+	Fields []discordEmbedField `json:"fields,omitempty"`
+}
+
+type discordMessage struct {
+	Username string         `json:"username"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+// DiscordSender posts notifications as embeds to discord webhooks
+type DiscordSender struct {
+	client *http.Client
+}
+
+func NewDiscordSender() *DiscordSender {
+	return &DiscordSender{client: &http.Client{Timeout: httpTimeout}}
+}
+
+// newDiscordMessage creates discord webhook payload for notification
+func newDiscordMessage(n services.Notification) discordMessage {
+	embed := discordEmbed{Title: Title(n), Color: discordColor}
+	for _, f := range fields(n) {
+		embed.Fields = append(embed.Fields, discordEmbedField{Name: f.Name, Value: f.Value, Inline: true})
+	}
+	return discordMessage{Username: "p2phub", Embeds: []discordEmbed{embed}}
+}
+
+func (s *DiscordSender) Send(target string, n services.Notification) error {
+	return postJSON(s.client, target, newDiscordMessage(n))
+}
@@ -0,0 +1,311 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"p2pbot/internal/config"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/rabbitmq"
+	"p2pbot/internal/rediscl"
+	"p2pbot/internal/services"
+	"strconv"
+	texttemplate "text/template"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	textDigest = texttemplate.Must(texttemplate.ParseFS(templates, "templates/digest.txt"))
+	htmlDigest = htmltemplate.Must(htmltemplate.ParseFS(templates, "templates/digest.html"))
+)
+
+// digestUsers is redis set of users with queued email notifications
+const digestUsers = "email:digest:users"
+
+// flushInterval is how often queued notifications are checked
+const flushInterval = time.Minute
+
+func digestKey(userID int) string {
+	return fmt.Sprintf("email:digest:%d", userID)
+}
+
+func digestLockKey(userID int) string {
+	return fmt.Sprintf("email:sent:%d", userID)
+}
+
+/*
+EmailNotifier sends notifications to users without connected telegram by email.
+Notifications are batched to digests, at most one email
+is sent to user every digest interval
+*/
+type EmailNotifier struct {
+	userService         *services.UserService
+	notificationService *services.NotificationService
+	from                string
+	digestInterval      time.Duration
+	unsubscribeURL      string
+	secret              string
+	send                func(to string, msg []byte) error
+}
+
+func NewEmailNotifier(cfg *config.Config,
+	userService *services.UserService,
+	notificationService *services.NotificationService) *EmailNotifier {
+	interval := time.Duration(cfg.Email.DigestInterval) * time.Minute
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	addr := cfg.Email.Host + ":" + cfg.Email.Port
+	var auth smtp.Auth
+	if cfg.Email.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Email.Username, cfg.Email.Password, cfg.Email.Host)
+	}
+	from := cfg.Email.From
+	return &EmailNotifier{
+		userService:         userService,
+		notificationService: notificationService,
+		from:                from,
+		digestInterval:      interval,
+		unsubscribeURL:      cfg.Email.UnsubscribeURL,
+		secret:              cfg.Website.JWTSecret,
+		send: func(to string, msg []byte) error {
+			return smtp.SendMail(addr, auth, envelopeAddress(from), []string{to}, msg)
+		},
+	}
+}
+
+// envelopeAddress returns address of "Name <address>"
+func envelopeAddress(from string) string {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return from
+	}
+	return addr.Address
+}
+
+// HandleNotification queues notification of user without telegram
+// and sends digest if user didn't receive email during digest interval
+func (en *EmailNotifier) HandleNotification(msg amqp.Delivery) error {
+	if msg.ContentType != "application/json" {
+		return fmt.Errorf("%w: invalid content type %s", rabbitmq.ErrPoison, msg.ContentType)
+	}
+	n, err := services.DecodeNotification(msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPoison, err)
+	}
+	// Users with telegram get notifications there
+	if n.UserID == 0 || n.ChatID != 0 || !services.AllowsChannel(n.Channels, models.ChannelEmail) {
+		return nil
+	}
+	user, err := en.userService.GetUserByID(n.UserID)
+	if err != nil {
+		return fmt.Errorf("error retreiving user: %v", err)
+	}
+	if !emailRecipient(user) {
+		return nil
+	}
+
+	// digest stores envelopes, advertisement of notification is exchange independent
+	ctx := rediscl.RDB.Ctx
+	pipe := rediscl.RDB.Client.TxPipeline()
+	pipe.RPush(ctx, digestKey(user.ID), msg.Body)
+	pipe.SAdd(ctx, digestUsers, user.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error queueing email notification: %v", err)
+	}
+	// digest which is not sent now is sent by Start
+	en.flushUser(user)
+	return nil
+}
+
+// emailRecipient returns true if notification emails should be sent to user
+func emailRecipient(user *models.User) bool {
+	return user.ChatID == nil && user.Email != nil && *user.Email != "" && user.EmailNotifications
+}
+
+// Start sends queued digests every minute until ctx is done
+func (en *EmailNotifier) Start(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			en.Flush()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush sends digests of users whose digest interval passed
+func (en *EmailNotifier) Flush() {
+	ctx := rediscl.RDB.Ctx
+	ids, err := rediscl.RDB.Client.SMembers(ctx, digestUsers).Result()
+	if err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error getting email digests")
+		return
+	}
+	for _, id := range ids {
+		userID, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		user, err := en.userService.GetUserByID(userID)
+		if err != nil {
+			log.Error().Str("error", err.Error()).Msg("Error retreiving user")
+			continue
+		}
+		en.flushUser(user)
+	}
+}
+
+// flushUser sends queued notifications of user if no email was sent during digest interval
+func (en *EmailNotifier) flushUser(user *models.User) {
+	ctx := rediscl.RDB.Ctx
+	queued, err := rediscl.RDB.Client.LLen(ctx, digestKey(user.ID)).Result()
+	if err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error getting email digest")
+		return
+	}
+	if queued == 0 {
+		rediscl.RDB.Client.SRem(ctx, digestUsers, user.ID)
+		return
+	}
+	// User connected telegram or unsubscribed after notifications were queued
+	if !emailRecipient(user) {
+		rediscl.RDB.Client.Del(ctx, digestKey(user.ID))
+		rediscl.RDB.Client.SRem(ctx, digestUsers, user.ID)
+		return
+	}
+	ok, err := rediscl.RDB.Client.SetNX(ctx, digestLockKey(user.ID), 1, en.digestInterval).Result()
+	if err != nil || !ok {
+		return
+	}
+
+	// Items are removed only after digest is sent, notifications
+	// queued while sending stay in the list for the next digest
+	items, err := rediscl.RDB.Client.LRange(ctx, digestKey(user.ID), 0, -1).Result()
+	if err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error getting email digest")
+		rediscl.RDB.Client.Del(ctx, digestLockKey(user.ID))
+		return
+	}
+	notifications := make([]services.Notification, 0, len(items))
+	for _, item := range items {
+		// items queued before envelopes are decoded too
+		n, err := services.DecodeNotification([]byte(item))
+		if err != nil {
+			continue
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err := en.SendDigest(*user.Email, user.ID, notifications); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": user.ID,
+		}).Msg("Error sending email digest")
+		// digest is retried by the next Flush
+		rediscl.RDB.Client.Del(ctx, digestLockKey(user.ID))
+		return
+	}
+	// user is removed from digest users by Flush once list is empty
+	if err := rediscl.RDB.Client.LTrim(ctx, digestKey(user.ID), int64(len(items)), -1).Err(); err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error removing sent email digest")
+	}
+	for _, n := range notifications {
+		if n.ID != 0 {
+			en.notificationService.MarkSent(n.ID, 0)
+		}
+	}
+}
+
+// SendDigest emails notifications to user in one message
+func (en *EmailNotifier) SendDigest(to string, userID int, notifications []services.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	unsubscribe := en.unsubscribeURL + "?token=" + url.QueryEscape(services.UnsubscribeToken(en.secret, userID))
+	msg, err := BuildDigest(en.from, to, unsubscribe, notifications)
+	if err != nil {
+		return err
+	}
+	return en.send(to, msg)
+}
+
+type digestItem struct {
+	Title  string
+	Fields []field
+}
+
+/*
+BuildDigest creates multipart email with text and html versions of notifications,
+unsubscribe url is added to body and List-Unsubscribe header
+*/
+func BuildDigest(from, to, unsubscribeURL string, notifications []services.Notification) ([]byte, error) {
+	data := struct {
+		Notifications  []digestItem
+		UnsubscribeURL string
+	}{UnsubscribeURL: unsubscribeURL}
+	for _, n := range notifications {
+		data.Notifications = append(data.Notifications, digestItem{Title: Title(n), Fields: fields(n)})
+	}
+	subject := fmt.Sprintf("p2phub: %d new notifications", len(notifications))
+	if len(notifications) == 1 {
+		subject = "p2phub: " + data.Notifications[0].Title
+	}
+
+	var text, html bytes.Buffer
+	if err := textDigest.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := htmlDigest.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	body := multipart.NewWriter(&msg)
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", to)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "List-Unsubscribe: <%s>\r\n", unsubscribeURL)
+	fmt.Fprintf(&msg, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", body.Boundary())
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		w.Write(part.content)
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"net/smtp"
+	"p2pbot/internal/services"
+	"strings"
+	"testing"
+)
+
+// smtpSink is local smtp server storing received messages
+type smtpSink struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	s := &smtpSink{listener: l, messages: make(chan string, 1)}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpSink) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 sink ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.messages <- data.String()
+			reply("250 queued")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSendDigest(t *testing.T) {
+	sink := newSMTPSink(t)
+	addr := sink.listener.Addr().String()
+	en := &EmailNotifier{
+		from:           "p2phub <notifications@p2phub.top>",
+		unsubscribeURL: "https://p2phub.top/api/v1/public/unsubscribe",
+		secret:         "secret",
+		send: func(to string, msg []byte) error {
+			return smtp.SendMail(addr, nil, envelopeAddress("p2phub <notifications@p2phub.top>"), []string{to}, msg)
+		},
+	}
+
+	second := testNotification()
+	second.Side = "BUY"
+	if err := en.SendDigest("trader@example.com", 7, []services.Notification{testNotification(), second}); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	msg := <-sink.messages
+
+	for _, want := range []string{
+		"To: trader@example.com",
+		"Subject: p2phub: 2 new notifications",
+		"List-Unsubscribe: <https://p2phub.top/api/v1/public/unsubscribe?token=7.",
+		"List-Unsubscribe-Post: List-Unsubscribe=One-Click",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+		"Your USDT/EUR SELL advertisement on okx was outbidded",
+		"Your USDT/EUR BUY advertisement on okx was outbidded",
+		"Advertiser: rival<3",
+		// html part is escaped
+		"rival&lt;3",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected email to contain %q", want)
+		}
+	}
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	if addr := envelopeAddress("p2phub <notifications@p2phub.top>"); addr != "notifications@p2phub.top" {
+		t.Errorf("unexpected address %s", addr)
+	}
+	if addr := envelopeAddress("notifications@p2phub.top"); addr != "notifications@p2phub.top" {
+		t.Errorf("unexpected address %s", addr)
+	}
+}
@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strings"
+)
+
+// field is a named value shown in discord embeds and slack sections
+type field struct {
+	Name  string
+	Value string
+}
+
+// Title returns one line summary of notification
+func Title(n services.Notification) string {
+	switch n.Kind {
+	case services.NotificationKindSummary:
+		return fmt.Sprintf("Quiet hours are over, %d notifications were held", len(n.Summary))
+	case models.TrackerKindPrice:
+		return fmt.Sprintf("%s/%s %s price on %s is %s %.2f%s",
+			n.Asset, n.Currency, n.Side, n.Exchange, n.Direction, n.Threshold, n.Currency)
+	case models.TrackerKindSpread:
+		return fmt.Sprintf("%s/%s %s spread between %s and %s is %.2f%%",
+			n.Asset, n.Currency, n.Side, n.Exchange, n.CompareExchange, n.Spread)
+	case services.NotificationKindArbitrage:
+		return fmt.Sprintf("Arbitrage %s/%s: buy on %s, sell on %s, %.2f%% after fees",
+			n.Asset, n.Currency, n.Exchange, n.CompareExchange, n.Spread)
+	}
+	return fmt.Sprintf("Your %s/%s %s advertisement on %s was outbidded", n.Asset, n.Currency, n.Side, n.Exchange)
+}
+
+// fields returns details of notification advertisement
+func fields(n services.Notification) []field {
+	if n.Kind == services.NotificationKindSummary {
+		return []field{{"Notifications", strings.Join(n.Summary, "\n")}}
+	}
+	if n.Data == nil {
+		return nil
+	}
+	q, minA, maxA := n.Data.GetQuantity()
+	out := []field{
+		{"Price", fmt.Sprintf("%.2f%s", n.Data.GetPrice(), n.Currency)},
+		{"Advertiser", n.Data.GetName()},
+		{"Quantity", fmt.Sprintf("%.2f%s", q, n.Asset)},
+		{"Limits", fmt.Sprintf("%.1f-%.1f%s", minA, maxA, n.Currency)},
+	}
+	adv := n.Data.ToAdvertisement()
+	out = append(out, field{"Advertiser stats", services.AdvertiserSummary(adv.Advertiser)})
+	if adv.PaymentTimeLimit > 0 {
+		out = append(out, field{"Payment time", fmt.Sprintf("%d min", adv.PaymentTimeLimit)})
+	}
+	if adv.Remarks != "" {
+		out = append(out, field{"Remarks", adv.Remarks})
+	}
+	methods := n.PaymentMethodNames()
+	if n.Kind == services.NotificationKindArbitrage {
+		methods = n.Methods
+		out = append(out, field{"Sell", fmt.Sprintf("%.2f%s by %s", n.ComparePrice, n.Currency, n.CompareName)})
+	}
+	if n.Kind == models.TrackerKindSpread {
+		out = append(out, field{n.CompareExchange, fmt.Sprintf("%.2f%s", n.ComparePrice, n.Currency)})
+	}
+	if len(methods) > 0 {
+		out = append(out, field{"Payment methods", strings.Join(methods, ", ")})
+	}
+	return out
+}
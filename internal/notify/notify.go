@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/rabbitmq"
+	"p2pbot/internal/services"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Sender delivers notification to channel target(chat id or webhook url)
+type Sender interface {
+	Send(target string, n services.Notification) error
+}
+
+// Notifier consumes notifications exchange and fans out
+// every notification to enabled channels of its user
+type Notifier struct {
+	channelService      *services.ChannelService
+	notificationService *services.NotificationService
+	// senders by channel kind
+	senders map[string]Sender
+}
+
+func NewNotifier(
+	channelService *services.ChannelService,
+	notificationService *services.NotificationService,
+	senders map[string]Sender) *Notifier {
+	return &Notifier{
+		channelService:      channelService,
+		notificationService: notificationService,
+		senders:             senders,
+	}
+}
+
+// HandleNotification delivers notification to user's channels,
+// notification is retried when it wasn't delivered to any channel
+func (nt *Notifier) HandleNotification(msg amqp.Delivery) error {
+	if msg.ContentType != "application/json" {
+		return fmt.Errorf("%w: invalid content type %s", rabbitmq.ErrPoison, msg.ContentType)
+	}
+	n, err := services.DecodeNotification(msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPoison, err)
+	}
+	// Notifications published before channels support have no user
+	if n.UserID == 0 {
+		return nil
+	}
+	enabled, err := nt.channelService.GetEnabled(n.UserID)
+	if err != nil {
+		return fmt.Errorf("error getting notification channels: %v", err)
+	}
+	// Only channels preferred in user settings
+	channels := make([]*models.NotificationChannel, 0, len(enabled))
+	for _, ch := range enabled {
+		if services.AllowsChannel(n.Channels, ch.Kind) {
+			channels = append(channels, ch)
+		}
+	}
+	if len(channels) == 0 {
+		return nil
+	}
+	sent, deliverErr := nt.Deliver(n, channels)
+	// Delivery of notifications to connected telegram is logged by bot
+	if n.ChatID == 0 && n.ID != 0 {
+		if sent > 0 {
+			err = nt.notificationService.MarkSent(n.ID, 0)
+		} else {
+			err = nt.notificationService.MarkFailed(n.ID, deliverErr)
+		}
+		if err != nil {
+			log.Error().Fields(map[string]interface{}{
+				"error": err.Error(),
+				"id":    n.ID,
+			}).Msg("Error updating notification status")
+		}
+	}
+	if sent == 0 {
+		return deliverErr
+	}
+	return nil
+}
+
+// Deliver sends notification to every channel,
+// returns number of channels it was delivered to and the last error
+func (nt *Notifier) Deliver(n services.Notification, channels []*models.NotificationChannel) (int, error) {
+	sent := 0
+	var lastErr error
+	for _, ch := range channels {
+		sender, ok := nt.senders[ch.Kind]
+		if !ok {
+			lastErr = fmt.Errorf("channel kind %s not supported", ch.Kind)
+			continue
+		}
+		if err := sender.Send(ch.Target, n); err != nil {
+			lastErr = err
+			log.Error().Fields(map[string]interface{}{
+				"error":   err.Error(),
+				"channel": ch.ID,
+				"kind":    ch.Kind,
+			}).Msg("Error delivering notification")
+			continue
+		}
+		sent++
+	}
+	return sent, lastErr
+}
+
+// httpTimeout limits requests to discord and slack
+const httpTimeout = 10 * time.Second
+
+// postJSON posts payload to webhook url, non 2xx response is an error
+func postJSON(client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strings"
+	"testing"
+)
+
+func testNotification() services.Notification {
+	return services.Notification{
+		TrackerID: 3,
+		UserID:    1,
+		Exchange:  "okx",
+		Asset:     "USDT",
+		Currency:  "EUR",
+		Side:      "SELL",
+		Data: services.OkxItem{
+			NickName:        "rival<3",
+			Price:           "0.95",
+			AvailableAmount: "100",
+			PaymentMethods:  []string{"REVOLUT"},
+		},
+	}
+}
+
+func TestDiscordSender(t *testing.T) {
+	var got discordMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Error: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := NewDiscordSender().Send(server.URL, testNotification()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(got.Embeds) != 1 {
+		t.Fatalf("expected 1 embed, got %d", len(got.Embeds))
+	}
+	embed := got.Embeds[0]
+	if embed.Title != "Your USDT/EUR SELL advertisement on okx was outbidded" {
+		t.Errorf("unexpected title %q", embed.Title)
+	}
+	if embed.Fields[0].Name != "Price" || embed.Fields[0].Value != "0.95EUR" {
+		t.Errorf("unexpected price field %+v", embed.Fields[0])
+	}
+}
+
+func TestSlackSender(t *testing.T) {
+	var got slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	if err := NewSlackSender().Send(server.URL, testNotification()); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(got.Blocks) != 2 || got.Text == "" {
+		t.Fatalf("unexpected message %+v", got)
+	}
+	if got.Blocks[1].Fields[1].Text != "*Advertiser*\nrival&lt;3" {
+		t.Errorf("expected escaped advertiser, got %q", got.Blocks[1].Fields[1].Text)
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewSlackSender().Send(server.URL, testNotification())
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected 403 error, got %v", err)
+	}
+}
+
+type fakeSender struct {
+	targets []string
+	err     error
+}
+
+func (s *fakeSender) Send(target string, n services.Notification) error {
+	s.targets = append(s.targets, target)
+	return s.err
+}
+
+func TestDeliver(t *testing.T) {
+	discord := &fakeSender{}
+	slack := &fakeSender{err: fmt.Errorf("slack is down")}
+	nt := NewNotifier(nil, nil, map[string]Sender{
+		models.ChannelDiscord: discord,
+		models.ChannelSlack:   slack,
+	})
+	channels := []*models.NotificationChannel{
+		{ID: 1, Kind: models.ChannelDiscord, Target: "team"},
+		{ID: 2, Kind: models.ChannelSlack, Target: "partner"},
+		{ID: 3, Kind: models.ChannelTelegram, Target: "42"},
+	}
+
+	sent, err := nt.Deliver(testNotification(), channels)
+	if sent != 1 || err == nil {
+		t.Errorf("expected 1 delivery with error, got %d, %v", sent, err)
+	}
+	if len(discord.targets) != 1 || discord.targets[0] != "team" || len(slack.targets) != 1 {
+		t.Errorf("unexpected deliveries %v %v", discord.targets, slack.targets)
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"p2pbot/internal/services"
+	"strings"
+)
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+type slackMessage struct {
+	// Text is shown in push notifications
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// SlackSender posts notifications to slack incoming webhooks
+type SlackSender struct {
+	client *http.Client
+}
+
+func NewSlackSender() *SlackSender {
+	return &SlackSender{client: &http.Client{Timeout: httpTimeout}}
+}
+
+// slackEscape escapes control characters of slack mrkdwn
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// newSlackMessage creates slack webhook payload for notification
+func newSlackMessage(n services.Notification) slackMessage {
+	title := Title(n)
+	msg := slackMessage{
+		Text: title,
+		Blocks: []slackBlock{{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: "*" + slackEscape(title) + "*"},
+		}},
+	}
+	details := slackBlock{Type: "section"}
+	for _, f := range fields(n) {
+		details.Fields = append(details.Fields, slackText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("*%s*\n%s", slackEscape(f.Name), slackEscape(f.Value)),
+		})
+	}
+	if len(details.Fields) > 0 {
+		msg.Blocks = append(msg.Blocks, details)
+	}
+	return msg
+}
+
+func (s *SlackSender) Send(target string, n services.Notification) error {
+	return postJSON(s.client, target, newSlackMessage(n))
+}
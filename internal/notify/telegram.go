@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"p2pbot/internal/bot"
+	"p2pbot/internal/services"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api"
+)
+
+// TelegramSender sends notifications to linked telegram chats, like team groups
+type TelegramSender struct {
+	api *tgbotapi.BotAPI
+}
+
+func NewTelegramSender(api *tgbotapi.BotAPI) *TelegramSender {
+	return &TelegramSender{api: api}
+}
+
+func (s *TelegramSender) Send(target string, n services.Notification) error {
+	chatID, err := strconv.ParseInt(target, 10, 64)
+	if err != nil {
+		return err
+	}
+	_, err = s.api.Send(tgbotapi.NewMessage(chatID, bot.FormatNotification(n)))
+	return err
+}
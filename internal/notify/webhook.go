@@ -0,0 +1,264 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog/log"
+	"net"
+	"net/http"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/rabbitmq"
+	"p2pbot/internal/rediscl"
+	"p2pbot/internal/services"
+	"strconv"
+	"syscall"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers of webhook requests
+const (
+	SignatureHeader = "X-P2PHub-Signature"
+	TimestampHeader = "X-P2PHub-Timestamp"
+	EventHeader     = "X-P2PHub-Event"
+	DeliveryHeader  = "X-P2PHub-Delivery"
+)
+
+// Webhook delivery retries
+const (
+	webhookAttempts = 5
+	// webhookBackoff is delay before the second attempt, doubled after every attempt
+	webhookBackoff = 2 * time.Second
+	// deliveredTTL is longer than all retries of message by queue
+	deliveredTTL = time.Hour
+)
+
+// WebhookDispatcher posts signed notification events to user's webhooks
+type WebhookDispatcher struct {
+	webhookService *services.WebhookService
+	client         *http.Client
+	attempts       int
+	backoff        time.Duration
+	sleep          func(time.Duration)
+}
+
+func NewWebhookDispatcher(webhookService *services.WebhookService) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookService: webhookService,
+		client:         newWebhookClient(),
+		attempts:       webhookAttempts,
+		backoff:        webhookBackoff,
+		sleep:          time.Sleep,
+	}
+}
+
+// ErrPrivateAddress is returned when webhook host resolves to address which is not public
+var ErrPrivateAddress = errors.New("webhook address is not public")
+
+// StatusError is returned when webhook responded with status other than 2xx
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webhook responded %d", e.StatusCode)
+}
+
+/*
+newWebhookClient returns http client which connects only to public addresses
+and doesn't follow redirects.
+
+Address is checked after resolving on every connect,
+so host re-pointed to internal network after validation is refused too
+*/
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: httpTimeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !services.PublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   httpTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+/*
+HandleNotification posts notification to enabled webhooks of its user.
+
+Webhooks are delivered in parallel, so retries of slow endpoint don't delay
+other webhooks, and message is acknowledged only after every delivery finished.
+Events not delivered after all retries are stored as dead letters,
+message is retried by queue if dead letter can't be stored.
+Event id is the same on redelivery and webhooks which already got it are skipped
+*/
+func (d *WebhookDispatcher) HandleNotification(msg amqp.Delivery) error {
+	if msg.ContentType != "application/json" {
+		return fmt.Errorf("%w: invalid content type %s", rabbitmq.ErrPoison, msg.ContentType)
+	}
+	n, err := services.DecodeNotification(msg.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPoison, err)
+	}
+	if n.UserID == 0 || !services.AllowsChannel(n.Channels, models.ChannelWebhook) {
+		return nil
+	}
+	webhooks, err := d.webhookService.GetEnabled(n.UserID)
+	if err != nil {
+		return fmt.Errorf("error getting webhooks: %v", err)
+	}
+	event := services.NewWebhookEvent(services.MessageEventID(msg.Body), n)
+	// at most MaxWebhooks deliveries of one message run at once
+	errs := make(chan error, len(webhooks))
+	for _, w := range webhooks {
+		go func(w *models.Webhook) {
+			// webhooks which got event before message was redelivered are skipped
+			if delivered(event.ID, w.ID) {
+				errs <- nil
+				return
+			}
+			errs <- d.deliverOrDeadLetter(w, event)
+		}(w)
+	}
+	for range webhooks {
+		if werr := <-errs; werr != nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// deliveredKey is set of webhooks event was delivered or dead-lettered to
+func deliveredKey(eventID string) string {
+	return "webhooks:delivered:" + eventID
+}
+
+// delivered returns true if event was already delivered to webhook,
+// events are delivered again if redis is unavailable
+func delivered(eventID string, webhookID int64) bool {
+	ok, err := rediscl.RDB.Client.SIsMember(rediscl.RDB.Ctx, deliveredKey(eventID), webhookID).Result()
+	return err == nil && ok
+}
+
+// markDelivered remembers that event is delivered to webhook until message can't be redelivered
+func markDelivered(eventID string, webhookID int64) {
+	ctx := rediscl.RDB.Ctx
+	pipe := rediscl.RDB.Client.TxPipeline()
+	pipe.SAdd(ctx, deliveredKey(eventID), webhookID)
+	pipe.Expire(ctx, deliveredKey(eventID), deliveredTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error marking webhook event delivered")
+	}
+}
+
+// deliverOrDeadLetter delivers event to webhook or stores it as dead letter,
+// returns error only if undelivered event could not be stored
+func (d *WebhookDispatcher) deliverOrDeadLetter(w *models.Webhook, event services.WebhookEvent) error {
+	attempts, err := d.Deliver(w, event)
+	if err == nil {
+		markDelivered(event.ID, w.ID)
+		return nil
+	}
+	log.Error().Fields(map[string]interface{}{
+		"error":    err.Error(),
+		"webhook":  w.ID,
+		"event":    event.ID,
+		"attempts": attempts,
+	}).Msg("Webhook delivery failed")
+
+	payload, _ := json.Marshal(event)
+	dl := &models.WebhookDeadLetter{
+		WebhookID: w.ID,
+		EventID:   event.ID,
+		Event:     event.Type,
+		Payload:   payload,
+		Attempts:  attempts,
+		Error:     err.Error(),
+	}
+	if err := d.webhookService.SaveDeadLetter(dl); err != nil {
+		return fmt.Errorf("error saving dead letter of webhook %d: %v", w.ID, err)
+	}
+	markDelivered(event.ID, w.ID)
+	return nil
+}
+
+/*
+Deliver posts event to webhook, retrying with exponential backoff
+on network errors, 5xx and 429 responses.
+Returns number of attempts and the last error if event was not delivered
+*/
+func (d *WebhookDispatcher) Deliver(w *models.Webhook, event services.WebhookEvent) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	delay := d.backoff
+	attempt := 0
+	for {
+		attempt++
+		retry, err := d.post(w, event, body)
+		if err == nil {
+			return attempt, nil
+		}
+		if !retry || attempt >= d.attempts {
+			return attempt, err
+		}
+		d.sleep(delay)
+		delay *= 2
+	}
+}
+
+// DeliverOnce posts event to webhook without retries, used for test events
+func (d *WebhookDispatcher) DeliverOnce(w *models.Webhook, event services.WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = d.post(w, event, body)
+	return err
+}
+
+// post sends signed event, returns true if failed request can be retried
+func (d *WebhookDispatcher) post(w *models.Webhook, event services.WebhookEvent, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	// Signature is renewed on every attempt, so retries are not rejected as stale
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "p2phub-webhooks")
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, "sha256="+services.SignPayload(w.Secret, ts, body))
+	req.Header.Set(EventHeader, event.Type)
+	req.Header.Set(DeliveryHeader, event.ID)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return !errors.Is(err, ErrPrivateAddress), err
+	}
+	// response body is never read, it must not be shown to user
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+	err = &StatusError{StatusCode: resp.StatusCode}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestDispatcher() (*WebhookDispatcher, *[]time.Duration) {
+	delays := make([]time.Duration, 0)
+	d := NewWebhookDispatcher(nil)
+	// test servers listen on loopback which webhook client refuses
+	d.client.Transport = http.DefaultTransport
+	d.sleep = func(delay time.Duration) { delays = append(delays, delay) }
+	return d, &delays
+}
+
+func TestWebhookPrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	d := NewWebhookDispatcher(nil)
+	d.sleep = func(time.Duration) {}
+	attempts, err := d.Deliver(&models.Webhook{URL: server.URL, Secret: "s"}, services.NewWebhookEvent("evt_test", testNotification()))
+	if !errors.Is(err, ErrPrivateAddress) || attempts != 1 {
+		t.Errorf("expected private address error without retries, got %d, %v", attempts, err)
+	}
+	if called {
+		t.Error("loopback webhook must not be called")
+	}
+}
+
+func TestWebhookRedirectNotFollowed(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect must not be followed")
+	}))
+	defer target.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	d, _ := newTestDispatcher()
+	err := d.DeliverOnce(&models.Webhook{URL: server.URL, Secret: "s"}, services.NewWebhookEvent("evt_test", testNotification()))
+	var status *StatusError
+	if !errors.As(err, &status) || status.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("expected status error, got %v", err)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	var event services.WebhookEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+		if err != nil {
+			t.Errorf("invalid timestamp: %v", err)
+		}
+		if r.Header.Get(SignatureHeader) != "sha256="+services.SignPayload("whsec_test", ts, body) {
+			t.Error("invalid signature")
+		}
+		if r.Header.Get(EventHeader) != "outbid" {
+			t.Errorf("unexpected event header %s", r.Header.Get(EventHeader))
+		}
+		json.Unmarshal(body, &event)
+	}))
+	defer server.Close()
+
+	d, _ := newTestDispatcher()
+	hook := &models.Webhook{ID: 1, URL: server.URL, Secret: "whsec_test"}
+	attempts, err := d.Deliver(hook, services.NewWebhookEvent("evt_test", testNotification()))
+	if err != nil || attempts != 1 {
+		t.Fatalf("expected delivery in 1 attempt, got %d, %v", attempts, err)
+	}
+	if event.Type != "outbid" || event.Data.Advertiser != "rival<3" || event.Data.Price != 0.95 {
+		t.Errorf("unexpected event %+v", event)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		ok       bool
+		delays   []time.Duration
+	}{
+		{"recovers", []int{500, 503, 200}, 3, true, []time.Duration{2 * time.Second, 4 * time.Second}},
+		{"rate limited", []int{429, 204}, 2, true, []time.Duration{2 * time.Second}},
+		{"client error not retried", []int{400}, 1, false, []time.Duration{}},
+		{"gives up", []int{500, 500, 500, 500, 500, 500}, 5, false,
+			[]time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer server.Close()
+
+			d, delays := newTestDispatcher()
+			attempts, err := d.Deliver(&models.Webhook{URL: server.URL, Secret: "s"}, services.NewWebhookEvent("evt_test", testNotification()))
+			if attempts != tt.attempts || (err == nil) != tt.ok {
+				t.Errorf("expected %d attempts, ok %v, got %d, %v", tt.attempts, tt.ok, attempts, err)
+			}
+			if len(*delays) != len(tt.delays) {
+				t.Fatalf("expected delays %v, got %v", tt.delays, *delays)
+			}
+			for i := range tt.delays {
+				if (*delays)[i] != tt.delays[i] {
+					t.Errorf("expected delays %v, got %v", tt.delays, *delays)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+)
+
+// Message types sent to feed subscribers
+const (
+	// MessageSnapshot is full book, sent first and after every reconnect
+	MessageSnapshot = "snapshot"
+	// MessageDiff is change of book since previous message
+	MessageDiff = "diff"
+)
+
+// subscriberBuffer is number of messages queued for slow subscriber before it is dropped
+const subscriberBuffer = 16
+
+// Message is order book update sent to subscriber
+type Message struct {
+	Type string             `json:"type"`
+	Book models.BookKey     `json:"book"`
+	Ads  []services.BookAd  `json:"ads,omitempty"`
+	Diff *services.BookDiff `json:"diff,omitempty"`
+}
+
+// Source subscribes to messages published with routing key on exchange,
+// implemented by rabbitmq.RabbitMQ
+type Source interface {
+	Subscribe(exchange, key string) (<-chan amqp.Delivery, func(), error)
+}
+
+/*
+Hub shares live order books between websocket subscribers.
+
+Every book has one subscription to observer books and one snapshot,
+feed is closed when its last subscriber leaves
+*/
+type Hub struct {
+	source Source
+	mu     sync.Mutex
+	feeds  map[models.BookKey]*feed
+}
+
+type feed struct {
+	key    models.BookKey
+	closeS func()
+	// guarded by Hub.mu
+	ads  []services.BookAd
+	subs map[chan Message]struct{}
+}
+
+func NewHub(source Source) *Hub {
+	return &Hub{source: source, feeds: make(map[models.BookKey]*feed)}
+}
+
+/*
+Subscribe returns channel of book updates, first message is current snapshot
+if book was already fetched. Channel is closed when subscriber is too slow
+or feed stops, returned function unsubscribes
+*/
+func (h *Hub) Subscribe(key models.BookKey) (<-chan Message, func(), error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, ok := h.feeds[key]
+	if !ok {
+		msgs, closeS, err := h.source.Subscribe(services.EventsExchange, services.BookEventsKey(key))
+		if err != nil {
+			return nil, nil, err
+		}
+		f = &feed{key: key, closeS: closeS, subs: make(map[chan Message]struct{})}
+		h.feeds[key] = f
+		go h.run(f, msgs)
+	}
+
+	ch := make(chan Message, subscriberBuffer)
+	f.subs[ch] = struct{}{}
+	if f.ads != nil {
+		ch <- Message{Type: MessageSnapshot, Book: key, Ads: f.ads}
+	}
+	return ch, func() { h.unsubscribe(f, ch) }, nil
+}
+
+func (h *Hub) unsubscribe(f *feed, ch chan Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := f.subs[ch]; !ok {
+		return
+	}
+	delete(f.subs, ch)
+	close(ch)
+	h.stopIdle(f)
+}
+
+// stopIdle closes subscription of feed without subscribers, h.mu must be held
+func (h *Hub) stopIdle(f *feed) {
+	if len(f.subs) > 0 || h.feeds[f.key] != f {
+		return
+	}
+	delete(h.feeds, f.key)
+	// closing channel waits for broker, run finishes when deliveries are closed
+	go f.closeS()
+}
+
+// run applies published books to feed snapshot and broadcasts diffs
+func (h *Hub) run(f *feed, msgs <-chan amqp.Delivery) {
+	for msg := range msgs {
+		var book services.Book
+		if err := json.Unmarshal(msg.Body, &book); err != nil {
+			log.Error().Msg(err.Error())
+			continue
+		}
+		h.apply(f, book.Ads)
+	}
+
+	// subscription closed, subscribers reconnect and get new feed
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.feeds[f.key] == f {
+		delete(h.feeds, f.key)
+	}
+	for ch := range f.subs {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+func (h *Hub) apply(f *feed, ads []services.BookAd) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var msg Message
+	if f.ads == nil {
+		msg = Message{Type: MessageSnapshot, Book: f.key, Ads: ads}
+	} else {
+		diff := services.DiffBook(f.ads, ads)
+		if diff.Empty() {
+			return
+		}
+		msg = Message{Type: MessageDiff, Book: f.key, Diff: &diff}
+	}
+	f.ads = ads
+	for ch := range f.subs {
+		select {
+		case ch <- msg:
+		default:
+			// slow subscriber missed update, it has to reconnect for new snapshot
+			log.Warn().Fields(map[string]interface{}{
+				"book": f.key,
+			}).Msg("Dropping slow order book subscriber")
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+	h.stopIdle(f)
+}
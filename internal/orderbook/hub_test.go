@@ -0,0 +1,131 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeSource counts subscriptions and publishes books to the latest one
+type fakeSource struct {
+	mu     sync.Mutex
+	subs   int
+	closed int
+	ch     chan amqp.Delivery
+}
+
+func (s *fakeSource) Subscribe(exchange, key string) (<-chan amqp.Delivery, func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subs++
+	ch := make(chan amqp.Delivery)
+	s.ch = ch
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.closed++
+			s.mu.Unlock()
+			close(ch)
+		})
+	}, nil
+}
+
+func (s *fakeSource) publish(t *testing.T, ads []services.BookAd) {
+	body, err := json.Marshal(services.Book{Ads: ads})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	ch := s.ch
+	s.mu.Unlock()
+	ch <- amqp.Delivery{Body: body}
+}
+
+func receive(t *testing.T, ch <-chan Message) Message {
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for message")
+	}
+	return Message{}
+}
+
+func TestHubSharesBook(t *testing.T) {
+	source := &fakeSource{}
+	hub := NewHub(source)
+	key := models.BookKey{Exchange: "binance", Asset: "USDT", Currency: "EUR", Side: "BUY"}
+
+	first, unsubFirst, err := hub.Subscribe(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.publish(t, []services.BookAd{{ID: "a", Price: 1}, {ID: "b", Price: 1.01}})
+	if msg := receive(t, first); msg.Type != MessageSnapshot || len(msg.Ads) != 2 {
+		t.Fatalf("expected snapshot, got %+v", msg)
+	}
+
+	// second subscriber gets shared snapshot without new subscription
+	second, unsubSecond, err := hub.Subscribe(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg := receive(t, second); msg.Type != MessageSnapshot || len(msg.Ads) != 2 {
+		t.Fatalf("expected snapshot, got %+v", msg)
+	}
+	if source.subs != 1 {
+		t.Errorf("expected one source subscription, got %d", source.subs)
+	}
+
+	source.publish(t, []services.BookAd{{ID: "a", Price: 0.99}, {ID: "c", Price: 1.02}})
+	for _, ch := range []<-chan Message{first, second} {
+		msg := receive(t, ch)
+		if msg.Type != MessageDiff || msg.Diff == nil {
+			t.Fatalf("expected diff, got %+v", msg)
+		}
+		if len(msg.Diff.Added) != 1 || len(msg.Diff.Removed) != 1 || len(msg.Diff.Changed) != 1 {
+			t.Errorf("unexpected diff %+v", msg.Diff)
+		}
+	}
+
+	unsubFirst()
+	unsubSecond()
+	time.Sleep(10 * time.Millisecond)
+	source.mu.Lock()
+	closed := source.closed
+	source.mu.Unlock()
+	if closed != 1 {
+		t.Errorf("expected source subscription closed after last subscriber, got %d", closed)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	source := &fakeSource{}
+	hub := NewHub(source)
+	key := models.BookKey{Exchange: "okx", Asset: "USDT", Currency: "EUR", Side: "SELL"}
+
+	ch, unsub, err := hub.Subscribe(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unsub()
+	for i := 0; i <= subscriberBuffer; i++ {
+		source.publish(t, []services.BookAd{{ID: "a", Price: float64(i + 1)}})
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("expected %d buffered messages before drop, got %d", subscriberBuffer, n)
+	}
+}
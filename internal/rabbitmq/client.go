@@ -1,15 +1,55 @@
 package rabbitmq
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/rs/zerolog/log"
 	"p2pbot/internal/config"
+	"sync"
+	"time"
 )
 
+// ErrNotConnected is returned when connection to broker is not restored within publish timeout
+var ErrNotConnected = errors.New("rabbitmq is not connected")
+
+// ErrClosed is returned after client is closed
+var ErrClosed = errors.New("rabbitmq client is closed")
+
+// Reconnection backoff, delay doubles after every failed attempt
+const (
+	reconnectDelay    = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+/*
+RabbitMQ is supervised connection to broker.
+
+When connection or channel is closed by broker, client reconnects with backoff,
+declares exchanges and queues declared before and starts consumers again.
+Publishing while disconnected waits for reconnection up to publish timeout
+and returns ErrNotConnected after it
+*/
 type RabbitMQ struct {
-	conn         *amqp.Connection
-	Ch           *amqp.Channel
+	url          string
 	ExchangeName string
+
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+	// ready is closed while connected, replaced when connection is lost
+	ready chan struct{}
+	// topology is declared again on new channel after reconnect
+	topology  []func(ch *amqp.Channel) error
+	consumers []consumer
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type consumer struct {
+	queue   string
+	handler func(amqp.Delivery) error
 }
 
 func NewRabbitMQ(cfg *config.Config) (*RabbitMQ, error) {
@@ -17,88 +57,312 @@ func NewRabbitMQ(cfg *config.Config) (*RabbitMQ, error) {
 		"url": cfg.RabbitMQ.URL,
 	}).Msg("Connecting to RabbitMQ")
 
-	conn, err := amqp.Dial(cfg.RabbitMQ.URL)
+	return Dial(cfg.RabbitMQ.URL)
+}
+
+// Dial connects to broker at url and supervises connection until Close
+func Dial(url string) (*RabbitMQ, error) {
+	r := &RabbitMQ{url: url, ready: make(chan struct{}), done: make(chan struct{})}
+	conn, ch, err := r.connect()
 	if err != nil {
 		return nil, err
 	}
+	if err := r.restore(conn, ch); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go r.supervise(conn, ch)
+	return r, nil
+}
+
+// Close stops reconnecting and closes connection
+func (r *RabbitMQ) Close() error {
+	r.closeOnce.Do(func() { close(r.done) })
+	r.mu.Lock()
+	conn := r.conn
+	r.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+	return conn.Close()
+}
+
+func (r *RabbitMQ) connect() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(r.url)
+	if err != nil {
+		return nil, nil, err
+	}
 	ch, err := conn.Channel()
 	if err != nil {
-		return nil, err
+		conn.Close()
+		return nil, nil, err
+	}
+	// broker acknowledges every message published on the channel
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, ch, nil
+}
+
+// restore declares topology and starts consumers on new channel and makes it current
+func (r *RabbitMQ) restore(conn *amqp.Connection, ch *amqp.Channel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, declare := range r.topology {
+		if err := declare(ch); err != nil {
+			return err
+		}
+	}
+	for _, c := range r.consumers {
+		if err := r.consume(ch, c); err != nil {
+			return err
+		}
+	}
+	r.conn, r.ch = conn, ch
+	close(r.ready)
+	return nil
+}
+
+// supervise waits until connection or channel is lost and reconnects
+func (r *RabbitMQ) supervise(conn *amqp.Connection, ch *amqp.Channel) {
+	for {
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+		var reason *amqp.Error
+		select {
+		case <-r.done:
+			return
+		case reason = <-connClosed:
+		case reason = <-chClosed:
+			// channel errors leave connection open, it is replaced as a whole
+			conn.Close()
+		}
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+		log.Error().Fields(map[string]interface{}{
+			"error": fmt.Sprint(reason),
+		}).Msg("RabbitMQ connection lost, reconnecting")
+
+		r.mu.Lock()
+		r.conn, r.ch = nil, nil
+		r.ready = make(chan struct{})
+		r.mu.Unlock()
+
+		if conn, ch = r.reconnect(); conn == nil {
+			return
+		}
+	}
+}
+
+// reconnect dials broker with backoff until it succeeds or client is closed
+func (r *RabbitMQ) reconnect() (*amqp.Connection, *amqp.Channel) {
+	delay := reconnectDelay
+	for {
+		select {
+		case <-r.done:
+			return nil, nil
+		case <-time.After(delay):
+		}
+		conn, ch, err := r.connect()
+		if err == nil {
+			if err = r.restore(conn, ch); err == nil {
+				log.Info().Msg("RabbitMQ reconnected")
+				return conn, ch
+			}
+			conn.Close()
+		}
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+			"retry": delay.String(),
+		}).Msg("Error reconnecting to RabbitMQ")
+		delay = min(delay*2, maxReconnectDelay)
 	}
-	return &RabbitMQ{conn, ch, ""}, nil
+}
+
+// current returns connection and channel, waiting for reconnection until ctx is done
+func (r *RabbitMQ) current(ctx context.Context) (*amqp.Connection, *amqp.Channel, error) {
+	for {
+		r.mu.Lock()
+		conn, ch, ready := r.conn, r.ch, r.ready
+		r.mu.Unlock()
+		if ch != nil && !ch.IsClosed() {
+			return conn, ch, nil
+		}
+		wait := (<-chan time.Time)(nil)
+		if ch != nil {
+			// connection is lost, but supervisor has not replaced it yet
+			ready = nil
+			wait = time.After(10 * time.Millisecond)
+		}
+		select {
+		case <-ready:
+		case <-wait:
+		case <-r.done:
+			return nil, nil, ErrClosed
+		case <-ctx.Done():
+			return nil, nil, ErrNotConnected
+		}
+	}
+}
+
+// declare runs declaration on current channel and remembers it for reconnect,
+// declarations made while disconnected run after reconnect
+func (r *RabbitMQ) declare(fn func(ch *amqp.Channel) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ch != nil {
+		if err := fn(r.ch); err != nil {
+			return err
+		}
+	}
+	r.topology = append(r.topology, fn)
+	return nil
 }
 
 func (r *RabbitMQ) DeclareExchange(name string) error {
 	r.ExchangeName = name
-	return r.Ch.ExchangeDeclare(
-		name,
-		"fanout",
-		true,
-		false,
+	return r.declare(func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(
+			name,
+			"fanout",
+			true,
+			false,
+			false,
+			false,
+			nil,
+		)
+	})
+}
+
+// DeclareTopicExchange declares durable topic exchange routing messages by key,
+// unlike DeclareExchange it doesn't change ExchangeName used by Publish
+func (r *RabbitMQ) DeclareTopicExchange(name string) error {
+	return r.declare(func(ch *amqp.Channel) error {
+		return ch.ExchangeDeclare(
+			name,
+			"topic",
+			true,
+			false,
+			false,
+			false,
+			nil,
+		)
+	})
+}
+
+// PublishTo publishes json message to exchange with routing key
+func (r *RabbitMQ) PublishTo(exchange, key string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, ch, err := r.current(ctx)
+	if err != nil {
+		return err
+	}
+	return ch.PublishWithContext(
+		ctx,
+		exchange,
+		key,
 		false,
 		false,
-		nil,
+		amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		},
 	)
 }
 
-func (r *RabbitMQ) QueueBindNDeclare() (string, error) {
-	q, err := r.Ch.QueueDeclare(
+/*
+Subscribe consumes messages of exchange with routing key through
+exclusive queue on its own channel, queue is deleted on close.
+Returned function closes subscription, deliveries are closed
+when connection is lost and subscriber has to subscribe again
+*/
+func (r *RabbitMQ) Subscribe(exchange, key string) (<-chan amqp.Delivery, func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	conn, _, err := r.current(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, nil, err
+	}
+	q, err := ch.QueueDeclare(
 		"",
-		true,
-		false,
 		false,
+		true,
+		true,
 		false,
 		nil,
 	)
 	if err != nil {
-		return "", err
+		ch.Close()
+		return nil, nil, err
 	}
-
-	err = r.Ch.QueueBind(
+	if err := ch.QueueBind(q.Name, key, exchange, false, nil); err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+	msgs, err := ch.Consume(
 		q.Name,
 		"",
-		r.ExchangeName,
+		true,
+		true,
+		false,
 		false,
 		nil,
 	)
 	if err != nil {
-		return "", err
+		ch.Close()
+		return nil, nil, err
 	}
-
-	return q.Name, nil
+	return msgs, func() { ch.Close() }, nil
 }
 
+// publishTimeout limits wait for broker confirmation of published message
+const publishTimeout = 5 * time.Second
+
+// Publish publishes persistent json message to ExchangeName and waits until broker confirms it
 func (r *RabbitMQ) Publish(body []byte) error {
-	return r.Ch.Publish(
-		r.ExchangeName,
-		"",
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+	return r.PublishTopic(r.ExchangeName, "", body)
 }
 
-func (r *RabbitMQ) StartConsuming(qName string, handlerFunc func(amqp.Delivery)) error {
-	msgs, err := r.Ch.Consume(
-		qName,
-		"",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
+// PublishTopic publishes persistent json message to exchange with routing key
+// and waits until broker confirms it
+func (r *RabbitMQ) PublishTopic(exchange, key string, body []byte) error {
+	return r.publishConfirmed(exchange, key, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Body:         body,
+	})
+}
+
+func (r *RabbitMQ) publishConfirmed(exchange, key string, msg amqp.Publishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+	defer cancel()
+	_, ch, err := r.current(ctx)
 	if err != nil {
 		return err
 	}
-
-	go func() {
-		for msg := range msgs {
-			handlerFunc(msg)
-		}
-	}()
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, msg)
+	if err != nil {
+		return err
+	}
+	// channel is not in confirm mode
+	if confirm == nil {
+		return nil
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("message was not confirmed: %v", err)
+	}
+	if !acked {
+		return fmt.Errorf("message was rejected by broker")
+	}
 	return nil
 }
@@ -0,0 +1,215 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rs/zerolog/log"
+)
+
+// Headers of retried and dead-lettered messages
+const (
+	// RetryHeader is number of times message was sent to retry queue
+	RetryHeader = "x-retry"
+	// ErrorHeader is error of the last failed delivery of dead-lettered message
+	ErrorHeader = "x-error"
+)
+
+// Durable queues of consumer types
+const (
+	// QueueTelegram is consumed by telegram bot
+	QueueTelegram = "notifications.telegram"
+	// QueueChannels is consumed by notifier delivering to discord, slack and telegram channels
+	QueueChannels = "notifications.channels"
+	// QueueWebhooks is consumed by notifier delivering to outgoing webhooks
+	QueueWebhooks = "notifications.webhooks"
+	// QueueEmail is consumed by notifier sending email digests
+	QueueEmail = "notifications.email"
+	// QueueCommands is consumed by observer
+	QueueCommands = "commands.observer"
+)
+
+// prefetchCount is number of unacknowledged messages delivered to consumer
+const prefetchCount = 10
+
+/*
+RetryDelays are delays before redelivery of message which handler failed,
+message is dead-lettered after it failed len(RetryDelays)+1 times
+*/
+var RetryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+// ErrPoison marks message which fails however many times it is delivered,
+// like invalid json, it is dead-lettered without retries
+var ErrPoison = errors.New("poison message")
+
+/*
+RetryQueue returns name of queue holding failed messages of queue until delay passes,
+like "notifications.telegram.retry.30s".
+
+Every delay has own queue with queue TTL, as broker expires only messages
+at the head of queue and longer delay would hold shorter ones behind it
+*/
+func RetryQueue(name string, delay time.Duration) string {
+	if delay%time.Minute == 0 {
+		return fmt.Sprintf("%s.retry.%dm", name, delay/time.Minute)
+	}
+	return fmt.Sprintf("%s.retry.%ds", name, delay/time.Second)
+}
+
+// DeadLetterQueue returns name of queue with messages of queue which can't be handled
+func DeadLetterQueue(name string) string {
+	return name + ".dlq"
+}
+
+/*
+DeclareQueue declares durable named queue bound to exchange with binding keys
+and its retry and dead-letter queues, queue of fanout exchange needs no keys.
+
+Every consumer type has own queue, so messages published
+while consumer is down are delivered after restart
+*/
+func (r *RabbitMQ) DeclareQueue(exchange, name string, keys ...string) error {
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+	return r.declare(func(ch *amqp.Channel) error {
+		if _, err := ch.QueueDeclare(DeadLetterQueue(name), true, false, false, false, nil); err != nil {
+			return err
+		}
+		// expired messages return to the queue through default exchange
+		for _, delay := range RetryDelays {
+			_, err := ch.QueueDeclare(RetryQueue(name, delay), true, false, false, false, amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": name,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		// rejected messages go to dead-letter queue
+		_, err := ch.QueueDeclare(name, true, false, false, false, amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": DeadLetterQueue(name),
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if err := ch.QueueBind(name, key, exchange, false, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+/*
+StartConsuming delivers messages of queue to handler with manual acks.
+
+Message is acknowledged when handler returns nil, otherwise it is
+sent to retry queue with delay from RetryDelays, or to dead-letter
+queue when retries are exhausted or handler returned ErrPoison
+*/
+func (r *RabbitMQ) StartConsuming(qName string, handlerFunc func(amqp.Delivery) error) error {
+	c := consumer{queue: qName, handler: handlerFunc}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// consumer is started by restore after reconnect
+	if r.ch != nil {
+		if err := r.consume(r.ch, c); err != nil {
+			return err
+		}
+	}
+	r.consumers = append(r.consumers, c)
+	return nil
+}
+
+// consume starts consumer on channel, deliveries stop when channel is closed
+func (r *RabbitMQ) consume(ch *amqp.Channel, c consumer) error {
+	if err := ch.Qos(prefetchCount, 0, false); err != nil {
+		return err
+	}
+	msgs, err := ch.Consume(
+		c.queue,
+		"",
+		false,
+		false,
+		false,
+		false,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for msg := range msgs {
+			r.settle(c.queue, msg, c.handler(msg))
+		}
+	}()
+	return nil
+}
+
+// Retries returns number of times message was sent to retry queue
+func Retries(msg amqp.Delivery) int {
+	switch v := msg.Headers[RetryHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// settle acknowledges handled message or moves failed one to retry or dead-letter queue
+func (r *RabbitMQ) settle(qName string, msg amqp.Delivery, err error) {
+	if err == nil {
+		if err := msg.Ack(false); err != nil {
+			log.Error().Str("error", err.Error()).Msg("Error acknowledging message")
+		}
+		return
+	}
+
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	pub := amqp.Publishing{
+		Headers:      headers,
+		ContentType:  msg.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Body:         msg.Body,
+	}
+	retries := Retries(msg)
+	var key string
+	if errors.Is(err, ErrPoison) || retries >= len(RetryDelays) {
+		key = DeadLetterQueue(qName)
+		headers[ErrorHeader] = err.Error()
+	} else {
+		key = RetryQueue(qName, RetryDelays[retries])
+		headers[RetryHeader] = int32(retries + 1)
+	}
+	log.Error().Fields(map[string]interface{}{
+		"error":   err.Error(),
+		"queue":   qName,
+		"retries": retries,
+		"to":      key,
+	}).Msg("Error handling message")
+
+	if perr := r.publishConfirmed("", key, pub); perr != nil {
+		// message stays in the queue and is delivered again
+		log.Error().Str("error", perr.Error()).Msg("Error moving failed message")
+		if err := msg.Nack(false, true); err != nil {
+			log.Error().Str("error", err.Error()).Msg("Error rejecting message")
+		}
+		return
+	}
+	if err := msg.Ack(false); err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error acknowledging message")
+	}
+}
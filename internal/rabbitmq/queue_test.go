@@ -0,0 +1,42 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers amqp.Table
+		want    int
+	}{
+		{"first delivery", nil, 0},
+		{"int32", amqp.Table{RetryHeader: int32(2)}, 2},
+		{"int64", amqp.Table{RetryHeader: int64(3)}, 3},
+		{"invalid", amqp.Table{RetryHeader: "1"}, 0},
+	}
+	for _, tt := range tests {
+		if got := Retries(amqp.Delivery{Headers: tt.headers}); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestQueueNames(t *testing.T) {
+	for delay, want := range map[time.Duration]string{
+		5 * time.Second:  "notifications.telegram.retry.5s",
+		30 * time.Second: "notifications.telegram.retry.30s",
+		2 * time.Minute:  "notifications.telegram.retry.2m",
+		90 * time.Second: "notifications.telegram.retry.90s",
+	} {
+		if q := RetryQueue(QueueTelegram, delay); q != want {
+			t.Errorf("expected retry queue %s, got %s", want, q)
+		}
+	}
+	if q := DeadLetterQueue(QueueTelegram); q != "notifications.telegram.dlq" {
+		t.Errorf("unexpected dead-letter queue %s", q)
+	}
+}
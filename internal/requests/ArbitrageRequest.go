@@ -0,0 +1,12 @@
+package requests
+
+type ArbitrageRequest struct {
+	Asset        string   `json:"asset"`
+	Currency     string   `json:"currency"`
+	BuyExchange  string   `json:"buy_exchange"`
+	SellExchange string   `json:"sell_exchange"`
+	Threshold    *float64 `json:"threshold"`
+	Notify       *bool    `json:"notify"`
+	// Payment method names, common methods of both exchanges are used if empty
+	Payment []string `json:"payment_methods"`
+}
@@ -0,0 +1,9 @@
+package requests
+
+type ChannelRequest struct {
+	Kind string `json:"kind"`
+	// Target is discord/slack webhook url, telegram chats are linked with bot link
+	Target  string  `json:"target"`
+	Name    *string `json:"name"`
+	Enabled *bool   `json:"enabled"`
+}
@@ -0,0 +1,10 @@
+package requests
+
+type SettingsRequest struct {
+	Timezone   string `json:"timezone"`
+	QuietStart string `json:"quiet_start"`
+	QuietEnd   string `json:"quiet_end"`
+	MaxPerHour int    `json:"max_per_hour"`
+	// Channels are preferred channel kinds, empty for all channels
+	Channels []string `json:"channels"`
+}
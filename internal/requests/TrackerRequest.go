@@ -1,11 +1,33 @@
 package requests
 
+import "p2pbot/internal/db/models"
+
 type TrackerRequest struct {
 	Exchange     string   `json:"exchange"`
+	Asset        string   `json:"asset"`
 	Currency     string   `json:"currency"`
 	Side         string   `json:"side"`
 	Username     string   `json:"username"`
 	IsAggregated bool     `json:"is_aggregated"`
 	Notify       *bool    `json:"notify"`
 	Payment      []string `json:"payment_methods"`
+	// Market alert fields, Kind defaults to outbid
+	Kind            string  `json:"kind"`
+	Threshold       float64 `json:"threshold"`
+	Direction       string  `json:"direction"`
+	CompareExchange string  `json:"compare_exchange"`
+	// Polling interval in seconds, limited by subscription
+	Interval *int `json:"interval"`
+	// Outbid rules(min_gap, gap_is_percent, min_quantity, min_completion_rate, min_orders, only_merchants),
+	// nil if none of them provided
+	*models.OutbidRules
+	// Nicknames which never count as outbidders
+	IgnoreNicknames []string `json:"ignore_nicknames"`
+	// Only these nicknames count as outbidders if not empty
+	OnlyNicknames []string `json:"only_nicknames"`
+}
+
+// TeamRequest contains nicknames of user's team accounts
+type TeamRequest struct {
+	Nicknames []string `json:"nicknames"`
 }
@@ -0,0 +1,7 @@
+package requests
+
+type WebhookRequest struct {
+	URL         string  `json:"url"`
+	Description *string `json:"description"`
+	Enabled     *bool   `json:"enabled"`
+}
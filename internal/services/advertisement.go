@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+Advertisement is exchange independent advertisement with advertiser stats,
+every exchange item maps to it with ToAdvertisement.
+
+Fields exchange doesn't return are zero
+*/
+type Advertisement struct {
+	// ID of advertisement on exchange
+	ID         string         `json:"id"`
+	Advertiser AdvertiserInfo `json:"advertiser"`
+	Price      float64        `json:"price"`
+	Quantity   float64        `json:"quantity"`
+	MinAmount  float64        `json:"min_amount"`
+	MaxAmount  float64        `json:"max_amount"`
+	// PaymentMethods are ids of payment methods
+	PaymentMethods []string `json:"payment_methods"`
+	// PaymentTimeLimit is minutes buyer has to pay
+	PaymentTimeLimit int    `json:"payment_time_limit"`
+	Remarks          string `json:"remarks"`
+}
+
+// AdvertiserInfo is author of advertisement
+type AdvertiserInfo struct {
+	ID       string `json:"id"`
+	Nickname string `json:"nickname"`
+	// OrderCount is number of recent orders
+	OrderCount int `json:"order_count"`
+	// CompletionRate of recent orders in percents
+	CompletionRate float64 `json:"completion_rate"`
+	// PositiveRate of feedback in percents
+	PositiveRate float64 `json:"positive_rate"`
+	// IsMerchant is true for verified merchants
+	IsMerchant bool `json:"is_merchant"`
+}
+
+func (a Advertisement) GetPrice() float64 {
+	return a.Price
+}
+
+func (a Advertisement) GetName() string {
+	return a.Advertiser.Nickname
+}
+
+func (a Advertisement) GetQuantity() (float64, float64, float64) {
+	return a.Quantity, a.MinAmount, a.MaxAmount
+}
+
+func (a Advertisement) GetPaymentMethods() []string {
+	return a.PaymentMethods
+}
+
+func (a Advertisement) GetOrderCount() int {
+	return a.Advertiser.OrderCount
+}
+
+func (a Advertisement) GetCompletionRate() float64 {
+	return a.Advertiser.CompletionRate
+}
+
+func (a Advertisement) ToAdvertisement() Advertisement {
+	return a
+}
+
+// AdvertiserSummary returns one line description of advertiser stats,
+// like "merchant, 1532 orders, 99.1% completed"
+func AdvertiserSummary(a AdvertiserInfo) string {
+	parts := make([]string, 0, 4)
+	if a.IsMerchant {
+		parts = append(parts, "merchant")
+	}
+	parts = append(parts, fmt.Sprintf("%d orders", a.OrderCount),
+		fmt.Sprintf("%.1f%% completed", a.CompletionRate))
+	if a.PositiveRate > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f%% positive", a.PositiveRate))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToAdvertisement(t *testing.T) {
+	tests := []struct {
+		exchange string
+		data     string
+		want     Advertisement
+	}{
+		{"binance", `{"adv":{"advNo":"1100","price":"1.01","tradableQuantity":"500","minSingleTransAmount":"10",
+			"maxSingleTransAmount":"400","tradeMethods":[{"identifier":"Wise"}],"payTimeLimit":15,"remarks":"fast"},
+			"advertiser":{"userNo":"u1","nickName":"rival","monthOrderCount":120,"monthFinishRate":0.98,
+			"positiveRate":0.995,"userType":"merchant"}}`,
+			Advertisement{ID: "1100", Price: 1.01, Quantity: 500, MinAmount: 10, MaxAmount: 400,
+				PaymentMethods: []string{"Wise"}, PaymentTimeLimit: 15, Remarks: "fast",
+				Advertiser: AdvertiserInfo{ID: "u1", Nickname: "rival", OrderCount: 120,
+					CompletionRate: 98, PositiveRate: 99.5, IsMerchant: true}}},
+		{"bybit", `{"id":"2200","userId":"u2","nickName":"rival","price":"1.01","quantity":"500","minAmount":"10",
+			"maxAmount":"400","payments":["14"],"recentOrderNum":120,"recentExecuteRate":98,
+			"paymentPeriod":15,"remark":"fast","authTag":["GA"]}`,
+			Advertisement{ID: "2200", Price: 1.01, Quantity: 500, MinAmount: 10, MaxAmount: 400,
+				PaymentMethods: []string{"14"}, PaymentTimeLimit: 15, Remarks: "fast",
+				Advertiser: AdvertiserInfo{ID: "u2", Nickname: "rival", OrderCount: 120,
+					CompletionRate: 98, IsMerchant: true}}},
+		{"okx", `{"id":"3300","merchantId":"u3","nickName":"rival","price":"1.01","availableAmount":"500",
+			"quoteMinAmountPerOrder":"10","quoteMaxAmountPerOrder":"400","paymentMethods":["SEPA"],
+			"completedOrderQuantity":120,"completedRate":"0.98","creatorType":"certified"}`,
+			Advertisement{ID: "3300", Price: 1.01, Quantity: 500, MinAmount: 10, MaxAmount: 400,
+				PaymentMethods: []string{"SEPA"},
+				Advertiser: AdvertiserInfo{ID: "u3", Nickname: "rival", OrderCount: 120,
+					CompletionRate: 98, IsMerchant: true}}},
+	}
+	for _, tt := range tests {
+		item, err := DecodeItem(tt.exchange, []byte(tt.data))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.exchange, err)
+		}
+		got := item.ToAdvertisement()
+		// rates are converted to percents with float multiplication
+		got.Advertiser.CompletionRate = roundRate(got.Advertiser.CompletionRate)
+		got.Advertiser.PositiveRate = roundRate(got.Advertiser.PositiveRate)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %+v, got %+v", tt.exchange, tt.want, got)
+		}
+	}
+}
+
+func roundRate(rate float64) float64 {
+	return float64(int(rate*10+0.5)) / 10
+}
+
+func TestAdvertiserSummary(t *testing.T) {
+	got := AdvertiserSummary(AdvertiserInfo{OrderCount: 1532, CompletionRate: 99.12, IsMerchant: true})
+	if got != "merchant, 1532 orders, 99.1% completed" {
+		t.Errorf("unexpected summary %s", got)
+	}
+	got = AdvertiserSummary(AdvertiserInfo{OrderCount: 3, CompletionRate: 100, PositiveRate: 98})
+	if got != "3 orders, 100.0% completed, 98.0% positive" {
+		t.Errorf("unexpected summary %s", got)
+	}
+}
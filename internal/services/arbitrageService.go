@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"p2pbot/internal/config"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// arbitrageDepth is number of best advertisements compared on each exchange
+const arbitrageDepth = 20
+
+type ArbitrageService struct {
+	repo      *repository.ArbitrageRepository
+	exchanges map[string]bool
+	// fees in percents per exchange
+	fees map[string]float64
+}
+
+func NewArbitrageService(repo *repository.ArbitrageRepository, exs Exchanges, cfg *config.Config) *ArbitrageService {
+	exchanges := make(map[string]bool)
+	for _, name := range exs.Names() {
+		exchanges[name] = true
+	}
+	return &ArbitrageService{repo: repo, exchanges: exchanges, fees: cfg.Exchange.Fees}
+}
+
+/*
+Validate checks arbitrage fields
+
+return error if asset, exchanges are not supported, exchanges are equal,
+currency length is not 3 or threshold is negative
+*/
+func (s *ArbitrageService) Validate(a *models.Arbitrage) error {
+	if a == nil {
+		return fmt.Errorf("Arbitrage is nil")
+	}
+
+	a.Asset = NormalizeAsset(a.Asset)
+	if !slices.Contains(SupportedAssets, a.Asset) {
+		return fmt.Errorf("asset %s not supported", a.Asset)
+	}
+
+	a.Currency = strings.ToUpper(a.Currency)
+	if len(a.Currency) != 3 {
+		return fmt.Errorf("Currency ticker must be 3 symbols long, EUR for example")
+	}
+
+	a.BuyExchange = strings.ToLower(a.BuyExchange)
+	a.SellExchange = strings.ToLower(a.SellExchange)
+	for _, ex := range []string{a.BuyExchange, a.SellExchange} {
+		if _, ok := s.exchanges[ex]; !ok {
+			return fmt.Errorf("exchange %s not supported", ex)
+		}
+	}
+	if a.BuyExchange == a.SellExchange {
+		return fmt.Errorf("Buy and sell exchanges must differ")
+	}
+
+	if a.Threshold < 0 {
+		return fmt.Errorf("Threshold must not be negative")
+	}
+	return nil
+}
+
+// Fees returns sum of fees(%) for buying on one exchange and selling on another
+func (s *ArbitrageService) Fees(buyExchange, sellExchange string) float64 {
+	return s.fees[buyExchange] + s.fees[sellExchange]
+}
+
+func (s *ArbitrageService) Save(a *models.Arbitrage) error {
+	return s.repo.Save(a)
+}
+
+func (s *ArbitrageService) GetByID(id int) (*models.Arbitrage, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ArbitrageService) GetByUserID(id int) ([]*models.Arbitrage, error) {
+	return s.repo.GetByUserID(id)
+}
+
+func (s *ArbitrageService) GetAll() ([]*models.Arbitrage, error) {
+	return s.repo.GetAll()
+}
+
+func (s *ArbitrageService) Delete(id int) error {
+	count, err := s.repo.Delete(id)
+	if count == 0 {
+		return fmt.Errorf("Arbitrage not found")
+	}
+	return err
+}
+
+// ArbitrageOpportunity is a pair of advertisements with common payment method
+type ArbitrageOpportunity struct {
+	// BuyAd is SELL advertisement on buy exchange
+	BuyAd P2PItemI
+	// SellAd is BUY advertisement on sell exchange
+	SellAd P2PItemI
+	// Methods are common payment method names
+	Methods []string
+	// Spread after fees in percents
+	Spread float64
+}
+
+// normalizeMethod makes payment method names comparable between exchanges,
+// "Revolut", "revolut" and "RE-VOLUT" are the same method
+func normalizeMethod(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// adMethods returns normalized name -> name of advertisement payment methods
+func adMethods(ad P2PItemI, names map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, id := range ad.GetPaymentMethods() {
+		name, ok := names[id]
+		if !ok {
+			name = id
+		}
+		out[normalizeMethod(name)] = name
+	}
+	return out
+}
+
+/*
+FindArbitrage returns the most profitable pair of advertisements with common payment method
+
+buyAds - SELL advertisements on buy exchange, sellAds - BUY advertisements on sell exchange,
+buyNames/sellNames - payment method id -> name for each exchange,
+methods - allowed payment method names, any common method is allowed if empty,
+fees - sum of fees in percents.
+return nil if there is no pair with common payment method
+*/
+func FindArbitrage(buyAds, sellAds []P2PItemI,
+	buyNames, sellNames map[string]string,
+	methods []string, fees float64) *ArbitrageOpportunity {
+
+	allowed := make(map[string]bool)
+	for _, m := range methods {
+		allowed[normalizeMethod(m)] = true
+	}
+
+	var best *ArbitrageOpportunity
+	for _, buyAd := range buyAds[:min(len(buyAds), arbitrageDepth)] {
+		buyMethods := adMethods(buyAd, buyNames)
+		for _, sellAd := range sellAds[:min(len(sellAds), arbitrageDepth)] {
+			common := make([]string, 0)
+			for key := range adMethods(sellAd, sellNames) {
+				if name, ok := buyMethods[key]; ok && (len(allowed) == 0 || allowed[key]) {
+					common = append(common, name)
+				}
+			}
+			if len(common) == 0 || buyAd.GetPrice() <= 0 {
+				continue
+			}
+			spread := (sellAd.GetPrice()-buyAd.GetPrice())/buyAd.GetPrice()*100 - fees
+			if best == nil || spread > best.Spread {
+				slices.Sort(common)
+				best = &ArbitrageOpportunity{
+					BuyAd:   buyAd,
+					SellAd:  sellAd,
+					Methods: common,
+					Spread:  spread,
+				}
+			}
+		}
+	}
+	return best
+}
+
+// PaymentMethodNames converts payment methods to id -> name map
+func PaymentMethodNames(pMethods []PaymentMethod) map[string]string {
+	out := make(map[string]string)
+	for _, pm := range pMethods {
+		out[pm.Id] = pm.Name
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"math"
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func TestFindArbitrage(t *testing.T) {
+	buyAds := []P2PItemI{
+		OkxItem{NickName: "cheap_paypal", Price: "0.90", PaymentMethods: []string{"PAYPAL"}},
+		OkxItem{NickName: "cheap_revolut", Price: "0.92", PaymentMethods: []string{"REVOLUT", "WISE"}},
+	}
+	sellAds := []P2PItemI{
+		OkxItem{NickName: "buyer_wise", Price: "0.97", PaymentMethods: []string{"7"}},
+		OkxItem{NickName: "buyer_revolut", Price: "0.95", PaymentMethods: []string{"9"}},
+	}
+	buyNames := map[string]string{"PAYPAL": "PayPal", "REVOLUT": "Revolut", "WISE": "Wise"}
+	sellNames := map[string]string{"7": "WISE", "9": "Re-volut"}
+
+	opp := FindArbitrage(buyAds, sellAds, buyNames, sellNames, nil, 0.5)
+	if opp == nil {
+		t.Fatal("expected arbitrage opportunity")
+	}
+	if opp.BuyAd.GetName() != "cheap_revolut" || opp.SellAd.GetName() != "buyer_wise" {
+		t.Errorf("unexpected pair %s -> %s", opp.BuyAd.GetName(), opp.SellAd.GetName())
+	}
+	want := (0.97-0.92)/0.92*100 - 0.5
+	if math.Abs(opp.Spread-want) > 1e-9 {
+		t.Errorf("expected spread %f, got %f", want, opp.Spread)
+	}
+	if len(opp.Methods) != 1 || opp.Methods[0] != "Wise" {
+		t.Errorf("unexpected methods %v", opp.Methods)
+	}
+
+	// Only Revolut is allowed
+	opp = FindArbitrage(buyAds, sellAds, buyNames, sellNames, []string{"revolut"}, 0)
+	if opp == nil || opp.SellAd.GetName() != "buyer_revolut" {
+		t.Fatalf("expected revolut pair, got %v", opp)
+	}
+
+	// No common payment methods
+	if opp := FindArbitrage(buyAds[:1], sellAds, buyNames, sellNames, nil, 0); opp != nil {
+		t.Errorf("expected no opportunity, got %v", opp)
+	}
+}
+
+func TestValidateArbitrage(t *testing.T) {
+	s := &ArbitrageService{exchanges: map[string]bool{"binance": true, "bybit": true}}
+
+	arb := &models.Arbitrage{Currency: "eur", BuyExchange: "Binance", SellExchange: "bybit", Threshold: 1}
+	if err := s.Validate(arb); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if arb.Asset != "USDT" || arb.Currency != "EUR" || arb.BuyExchange != "binance" {
+		t.Errorf("arbitrage not normalized: %+v", arb)
+	}
+
+	invalid := []*models.Arbitrage{
+		{Currency: "EUR", BuyExchange: "binance", SellExchange: "binance"},
+		{Currency: "EUR", BuyExchange: "binance", SellExchange: "okx"},
+		{Currency: "EURO", BuyExchange: "binance", SellExchange: "bybit"},
+		{Currency: "EUR", BuyExchange: "binance", SellExchange: "bybit", Threshold: -1},
+		{Asset: "DOGE", Currency: "EUR", BuyExchange: "binance", SellExchange: "bybit"},
+	}
+	for _, a := range invalid {
+		if err := s.Validate(a); err == nil {
+			t.Errorf("expected error for %+v", a)
+		}
+	}
+}
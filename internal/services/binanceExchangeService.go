@@ -60,6 +60,9 @@ type Adv struct {
 	MinSingleTransAmount string        `json:"minSingleTransAmount"`
 	TradeMethods         []TradeMethod `json:"tradeMethods"`
 	IsTradable           bool          `json:"isTradable"`
+	AdvNo                string        `json:"advNo"`
+	PayTimeLimit         int           `json:"payTimeLimit"`
+	Remarks              string        `json:"remarks"`
 }
 
 type TradeMethod struct {
@@ -71,6 +74,15 @@ type Advertiser struct {
 	MonthOrderCount int     `json:"monthOrderCount"`
 	MonthFinishRate float64 `json:"monthFinishRate"`
 	PositiveRate    float64 `json:"positiveRate"`
+	UserNo          string  `json:"userNo"`
+	// UserType is "merchant" for verified merchants
+	UserType string `json:"userType"`
+}
+
+func init() {
+	RegisterExchange("binance", func(cfg *config.Config) ExchangeI {
+		return NewBinanceExchange(cfg)
+	}, jsonItemDecoder[DataItem]())
 }
 
 func NewBinanceExchange(config *config.Config) *BinanceExchange {
@@ -86,7 +98,7 @@ func (ex BinanceExchange) GetName() string {
 	return ex.name
 }
 
-func (ex BinanceExchange) GetBestAdv(currency, side string, paymentMethods []string) (P2PItemI, error) {
+func (ex BinanceExchange) GetBestAdv(asset, currency, side string, paymentMethods []string) (P2PItemI, error) {
 	if side == "BUY" {
 		side = "SELL"
 	} else if side == "SELL" {
@@ -100,7 +112,7 @@ func (ex BinanceExchange) GetBestAdv(currency, side string, paymentMethods []str
 		Page:                      1,
 		Rows:                      10,
 		TradeType:                 side,
-		Asset:                     "USDT",
+		Asset:                     asset,
 		Countries:                 []string{},
 		ProMerchantAds:            false,
 		ShieldMerchantAds:         false,
@@ -175,6 +187,15 @@ func (i DataItem) GetPrice() float64 {
 	return price
 }
 
+func (i DataItem) GetOrderCount() int {
+	return i.Advertiser.MonthOrderCount
+}
+
+// GetCompletionRate converts binance finish rate(0.98) to percents
+func (i DataItem) GetCompletionRate() float64 {
+	return i.Advertiser.MonthFinishRate * 100
+}
+
 func (i DataItem) GetQuantity() (quantity, minAmount, maxAmount float64) {
 	quantity, _ = strconv.ParseFloat(i.Adv.TradableQuantity, 64)
 	minAmount, _ = strconv.ParseFloat(i.Adv.MinSingleTransAmount, 64)
@@ -182,7 +203,30 @@ func (i DataItem) GetQuantity() (quantity, minAmount, maxAmount float64) {
 	return
 }
 
-func (ex BinanceExchange) RequestData(page int, currency, side string, pMethods []string) (*BinanceAdsResponse, error) {
+// ToAdvertisement converts binance rates(0.98) to percents
+func (i DataItem) ToAdvertisement() Advertisement {
+	q, minA, maxA := i.GetQuantity()
+	return Advertisement{
+		ID: i.Adv.AdvNo,
+		Advertiser: AdvertiserInfo{
+			ID:             i.Advertiser.UserNo,
+			Nickname:       i.Advertiser.NickName,
+			OrderCount:     i.Advertiser.MonthOrderCount,
+			CompletionRate: i.GetCompletionRate(),
+			PositiveRate:   i.Advertiser.PositiveRate * 100,
+			IsMerchant:     i.Advertiser.UserType == "merchant",
+		},
+		Price:            i.GetPrice(),
+		Quantity:         q,
+		MinAmount:        minA,
+		MaxAmount:        maxA,
+		PaymentMethods:   i.GetPaymentMethods(),
+		PaymentTimeLimit: i.Adv.PayTimeLimit,
+		Remarks:          i.Adv.Remarks,
+	}
+}
+
+func (ex BinanceExchange) RequestData(page int, asset, currency, side string, pMethods []string) (*BinanceAdsResponse, error) {
 	if side == "BUY" {
 		side = "SELL"
 	} else if side == "SELL" {
@@ -196,7 +240,7 @@ func (ex BinanceExchange) RequestData(page int, currency, side string, pMethods
 		Page:                      page,
 		Rows:                      10,
 		TradeType:                 side,
-		Asset:                     "USDT",
+		Asset:                     asset,
 		Countries:                 []string{},
 		ProMerchantAds:            false,
 		ShieldMerchantAds:         false,
@@ -256,11 +300,11 @@ func (ex BinanceExchange) RequestData(page int, currency, side string, pMethods
 	return &binanceResponse, nil
 }
 
-func (ex BinanceExchange) GetAdsByName(currency, side, username string, pMethods []string) ([]P2PItemI, error) {
+func (ex BinanceExchange) GetAdsByName(asset, currency, side, username string, pMethods []string) ([]P2PItemI, error) {
 	out := make([]P2PItemI, 0)
 	i := 1
 	for {
-		response, err := ex.RequestData(i, currency, side, pMethods)
+		response, err := ex.RequestData(i, asset, currency, side, pMethods)
 		if err != nil {
 
 			return nil, fmt.Errorf("could not find advertisement with username %s", username)
@@ -283,11 +327,11 @@ func (ex BinanceExchange) GetAdsByName(currency, side, username string, pMethods
 	}
 }
 
-func (ex BinanceExchange) GetAds(currency, side string) ([]P2PItemI, error) {
+func (ex BinanceExchange) GetAds(asset, currency, side string) ([]P2PItemI, error) {
 	out := make([]P2PItemI, 0)
 	i := 1
 	for {
-		response, err := ex.RequestData(i, currency, side, []string{})
+		response, err := ex.RequestData(i, asset, currency, side, []string{})
 		if err != nil {
 			return nil, fmt.Errorf("error while getting advertisements %v", err)
 		}
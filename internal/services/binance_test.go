@@ -0,0 +1,57 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newBinanceTestExchange returns binance exchange which sends requests to server,
+// payloads of requests are sent to returned channel
+func newBinanceTestExchange(t *testing.T) (*BinanceExchange, <-chan BinancePayload) {
+	t.Helper()
+	payloads := make(chan BinancePayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload BinancePayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			// t.Fatalf must not be called from server goroutine
+			t.Errorf("could not decode payload: %v", err)
+		}
+		payloads <- payload
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"code":"000000","success":true,"total":1,"data":[{"adv":{"price":"0.00001","tradableQuantity":"2"},"advertiser":{"nickName":"rival"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return &BinanceExchange{
+		adsEndpoint: srv.URL,
+		name:        "Binance",
+		maxRetries:  1,
+	}, payloads
+}
+
+func TestBinanceRequestPayload(t *testing.T) {
+	binance, payloads := newBinanceTestExchange(t)
+
+	ad, err := binance.GetBestAdv("BTC", "EUR", "BUY", []string{"Revolut"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if ad.GetName() != "rival" {
+		t.Errorf("unexpected best ad %s", ad.GetName())
+	}
+	payload := <-payloads
+	// binance trade type is side of advertisement, not of user
+	if payload.Asset != "BTC" || payload.Fiat != "EUR" || payload.TradeType != "SELL" ||
+		len(payload.PayTypes) != 1 || payload.PayTypes[0] != "Revolut" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+
+	if _, err := binance.RequestData(2, "ETH", "USD", "SELL", nil); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	payload = <-payloads
+	if payload.Asset != "ETH" || payload.Fiat != "USD" || payload.TradeType != "BUY" || payload.Page != 2 {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+}
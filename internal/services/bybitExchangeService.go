@@ -50,6 +50,12 @@ type Item struct {
 	Payments          []string `json:"payments"`
 	RecentOrderNum    int      `json:"recentOrderNum"`
 	RecentExecuteRate int      `json:"recentExecuteRate"`
+	ID                string   `json:"id"`
+	UserID            string   `json:"userId"`
+	Remark            string   `json:"remark"`
+	PaymentPeriod     int      `json:"paymentPeriod"`
+	// AuthTag has badges of verified advertisers, empty for regular users
+	AuthTag []string `json:"authTag"`
 }
 
 type BybitPayment struct {
@@ -57,6 +63,12 @@ type BybitPayment struct {
 	PaymentName string `json:"paymentName"`
 }
 
+func init() {
+	RegisterExchange("bybit", func(cfg *config.Config) ExchangeI {
+		return NewBybitExcahnge(cfg)
+	}, jsonItemDecoder[Item]())
+}
+
 func NewBybitExcahnge(config *config.Config) *BybitExchange {
 	return &BybitExchange{
 		adsEndpoint: "https://api2.bybit.com/fiat/otc/item/online",
@@ -70,7 +82,7 @@ func (ex BybitExchange) GetName() string {
 	return ex.name
 }
 
-func (ex BybitExchange) GetBestAdv(currency, side string, paymentMethods []string) (P2PItemI, error) {
+func (ex BybitExchange) GetBestAdv(asset, currency, side string, paymentMethods []string) (P2PItemI, error) {
 	if side == "SELL" {
 		side = "1"
 	} else if side == "BUY" {
@@ -80,7 +92,7 @@ func (ex BybitExchange) GetBestAdv(currency, side string, paymentMethods []strin
 	}
 
 	payload := BybitPayload{
-		TokenID:    "USDT",
+		TokenID:    asset,
 		CurrencyID: currency,
 		Side:       side,
 		Payment:    paymentMethods,
@@ -151,11 +163,42 @@ func (i Item) GetQuantity() (quantity, minAmount, maxAmount float64) {
 	return
 }
 
+func (i Item) GetOrderCount() int {
+	return i.RecentOrderNum
+}
+
+// GetCompletionRate returns bybit execute rate, it is already in percents
+func (i Item) GetCompletionRate() float64 {
+	return float64(i.RecentExecuteRate)
+}
+
 func (i Item) GetName() string {
 	return i.NickName
 }
 
-func (ex BybitExchange) requestData(page int, currency, side string, pMethods []string) (*BybitAdsResponse, error) {
+// ToAdvertisement maps bybit item, bybit doesn't return positive rate in advertisements
+func (i Item) ToAdvertisement() Advertisement {
+	q, minA, maxA := i.GetQuantity()
+	return Advertisement{
+		ID: i.ID,
+		Advertiser: AdvertiserInfo{
+			ID:             i.UserID,
+			Nickname:       i.NickName,
+			OrderCount:     i.RecentOrderNum,
+			CompletionRate: i.GetCompletionRate(),
+			IsMerchant:     len(i.AuthTag) > 0,
+		},
+		Price:            i.GetPrice(),
+		Quantity:         q,
+		MinAmount:        minA,
+		MaxAmount:        maxA,
+		PaymentMethods:   i.Payments,
+		PaymentTimeLimit: i.PaymentPeriod,
+		Remarks:          i.Remark,
+	}
+}
+
+func (ex BybitExchange) requestData(page int, asset, currency, side string, pMethods []string) (*BybitAdsResponse, error) {
 	if side == "SELL" {
 		side = "1"
 	} else if side == "BUY" {
@@ -165,7 +208,7 @@ func (ex BybitExchange) requestData(page int, currency, side string, pMethods []
 	}
 
 	payload := BybitPayload{
-		TokenID:    "USDT",
+		TokenID:    asset,
 		CurrencyID: currency,
 		Side:       side,
 		Payment:    pMethods,
@@ -221,11 +264,11 @@ func (ex BybitExchange) requestData(page int, currency, side string, pMethods []
 	return &bybitResponse, nil
 }
 
-func (ex BybitExchange) GetAdsByName(currency, side, username string, pMethods []string) ([]P2PItemI, error) {
+func (ex BybitExchange) GetAdsByName(asset, currency, side, username string, pMethods []string) ([]P2PItemI, error) {
 	out := make([]P2PItemI, 0)
 	i := 1
 	for {
-		resp, err := ex.requestData(i, currency, side, pMethods)
+		resp, err := ex.requestData(i, asset, currency, side, pMethods)
 		if err != nil {
 			return nil, fmt.Errorf("could not find advertisement with username %s", username)
 		}
@@ -247,15 +290,16 @@ func (ex BybitExchange) GetAdsByName(currency, side, username string, pMethods [
 	}
 }
 
-// GetAds returns all advertisements for a given currency and side
-func (ex BybitExchange) GetAds(currency, side string) ([]P2PItemI, error) {
+// GetAds returns all advertisements for a given asset, currency and side
+func (ex BybitExchange) GetAds(asset, currency, side string) ([]P2PItemI, error) {
 	out := make([]P2PItemI, 0)
 	i := 1
 	for {
 		start := time.Now()
-		response, err := ex.requestData(i, currency, side, []string{})
+		response, err := ex.requestData(i, asset, currency, side, []string{})
 		log.Debug().
 			Int("page", i).
+			Str("asset", asset).
 			Str("currency", currency).
 			Str("side", side).
 			Int("len(ads)", len(response.Result.Items)).
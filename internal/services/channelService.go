@@ -0,0 +1,193 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"p2pbot/internal/rediscl"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/teris-io/shortid"
+)
+
+// MaxChannels is the most notification channels user can link
+const MaxChannels = 10
+
+// TelegramLinkTTL is how long link connecting telegram chat as channel is valid
+const TelegramLinkTTL = 15 * time.Minute
+
+// telegramLink is telegram channel waiting for /start with its code
+type telegramLink struct {
+	UserID  int    `json:"user_id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// webhookHosts are allowed hosts of incoming webhook urls per channel kind
+var webhookHosts = map[string][]string{
+	models.ChannelDiscord: {"discord.com", "discordapp.com"},
+	models.ChannelSlack:   {"hooks.slack.com"},
+}
+
+type ChannelService struct {
+	repo *repository.ChannelRepository
+}
+
+func NewChannelService(repo *repository.ChannelRepository) *ChannelService {
+	return &ChannelService{repo: repo}
+}
+
+/*
+ValidateChannel checks notification channel fields
+
+return error if kind is not telegram/discord/slack,
+telegram target is not chat id, discord or slack target
+is not https webhook url of the service or name is longer than 64 symbols.
+
+Telegram target is empty until chat is linked with NewTelegramLink code
+*/
+func ValidateChannel(ch *models.NotificationChannel) error {
+	if ch == nil {
+		return fmt.Errorf("Channel is nil")
+	}
+	ch.Kind = strings.ToLower(ch.Kind)
+	ch.Target = strings.TrimSpace(ch.Target)
+	ch.Name = strings.TrimSpace(ch.Name)
+	if len(ch.Name) > 64 {
+		return fmt.Errorf("Name must be at most 64 symbols long")
+	}
+
+	switch ch.Kind {
+	case models.ChannelTelegram:
+		if ch.Target == "" {
+			return nil
+		}
+		if _, err := strconv.ParseInt(ch.Target, 10, 64); err != nil {
+			return fmt.Errorf("Telegram target must be chat id")
+		}
+	case models.ChannelDiscord, models.ChannelSlack:
+		u, err := url.Parse(ch.Target)
+		if err != nil || u.Scheme != "https" {
+			return fmt.Errorf("%s target must be https webhook url", ch.Kind)
+		}
+		for _, host := range webhookHosts[ch.Kind] {
+			if u.Host == host {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s webhook host must be one of %v", ch.Kind, webhookHosts[ch.Kind])
+	default:
+		return fmt.Errorf("Kind must be telegram/discord/slack")
+	}
+	return nil
+}
+
+/*
+Validate checks channel with ValidateChannel
+
+return error if channel is invalid or new channel
+is added when user already has MaxChannels channels
+*/
+func (s *ChannelService) Validate(ch *models.NotificationChannel) error {
+	if err := ValidateChannel(ch); err != nil {
+		return err
+	}
+	if ch.ID == 0 {
+		channels, err := s.repo.GetByUserID(ch.UserID)
+		if err != nil {
+			return err
+		}
+		if len(channels) >= MaxChannels {
+			return fmt.Errorf("At most %d channels allowed", MaxChannels)
+		}
+	}
+	return nil
+}
+
+/*
+NewTelegramLink stores telegram channel until its chat is linked
+and returns one time code of bot link.
+
+Chat becomes target only when /start with code is sent from it,
+so users can't send notifications to chats they don't have access to
+*/
+func (s *ChannelService) NewTelegramLink(ch *models.NotificationChannel) (string, error) {
+	code, err := shortid.Generate()
+	if err != nil {
+		return "", err
+	}
+	link, err := json.Marshal(telegramLink{UserID: ch.UserID, Name: ch.Name, Enabled: ch.Enabled})
+	if err != nil {
+		return "", err
+	}
+	ctx := rediscl.RDB.Ctx
+	if err := rediscl.RDB.Client.Set(ctx, "telegram_channel_codes:"+code, link, TelegramLinkTTL).Err(); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+/*
+LinkTelegram saves telegram channel of code with chat as target.
+
+return nil channel if code doesn't exist or expired
+and error if channel is invalid or can't be saved
+*/
+func (s *ChannelService) LinkTelegram(code string, chatID int64) (*models.NotificationChannel, error) {
+	ctx := rediscl.RDB.Ctx
+	data, err := rediscl.RDB.Client.GetDel(ctx, "telegram_channel_codes:"+code).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var link telegramLink
+	if err := json.Unmarshal([]byte(data), &link); err != nil {
+		return nil, err
+	}
+	ch := &models.NotificationChannel{
+		UserID:  link.UserID,
+		Kind:    models.ChannelTelegram,
+		Target:  strconv.FormatInt(chatID, 10),
+		Name:    link.Name,
+		Enabled: link.Enabled,
+	}
+	if err := s.Validate(ch); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Save(ch); err != nil {
+		return nil, err
+	}
+	return ch, nil
+}
+
+func (s *ChannelService) Save(ch *models.NotificationChannel) error {
+	return s.repo.Save(ch)
+}
+
+func (s *ChannelService) GetByID(id int) (*models.NotificationChannel, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *ChannelService) GetByUserID(id int) ([]*models.NotificationChannel, error) {
+	return s.repo.GetByUserID(id)
+}
+
+// GetEnabled returns channels notifications of user are delivered to
+func (s *ChannelService) GetEnabled(userID int) ([]*models.NotificationChannel, error) {
+	return s.repo.GetEnabled(userID)
+}
+
+func (s *ChannelService) Delete(id int) error {
+	count, err := s.repo.Delete(id)
+	if count == 0 && err == nil {
+		return fmt.Errorf("Channel not found")
+	}
+	return err
+}
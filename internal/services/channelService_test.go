@@ -0,0 +1,34 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func TestValidateChannel(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel models.NotificationChannel
+		valid   bool
+	}{
+		{"telegram", models.NotificationChannel{Kind: "Telegram", Target: "-100123"}, true},
+		{"telegram username", models.NotificationChannel{Kind: "telegram", Target: "@team"}, false},
+		{"telegram not linked", models.NotificationChannel{Kind: "telegram"}, true},
+		{"discord", models.NotificationChannel{Kind: "discord", Target: "https://discord.com/api/webhooks/1/abc"}, true},
+		{"discord http", models.NotificationChannel{Kind: "discord", Target: "http://discord.com/api/webhooks/1/abc"}, false},
+		{"discord other host", models.NotificationChannel{Kind: "discord", Target: "https://example.com/hook"}, false},
+		{"slack", models.NotificationChannel{Kind: "slack", Target: " https://hooks.slack.com/services/T/B/x "}, true},
+		{"slack discord url", models.NotificationChannel{Kind: "slack", Target: "https://discord.com/api/webhooks/1/abc"}, false},
+		{"unknown kind", models.NotificationChannel{Kind: "email", Target: "me@example.com"}, false},
+	}
+	for _, tt := range tests {
+		ch := tt.channel
+		err := ValidateChannel(&ch)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid channel, got %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
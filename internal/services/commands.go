@@ -0,0 +1,13 @@
+package services
+
+// Command kinds sent by bot to observer
+const (
+	// CommandCheckTracker asks observer to check tracker immediately
+	CommandCheckTracker = "check_tracker"
+)
+
+// Command is a message published to commands exchange
+type Command struct {
+	Kind      string `json:"kind"`
+	TrackerID int64  `json:"tracker_id"`
+}
@@ -3,14 +3,87 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"math"
+	"p2pbot/internal/db/models"
+	"time"
 )
 
 type Notification struct {
+	// ID of notification delivery log record
+	ID        int64 `json:"id,omitempty"`
+	TrackerID int64 `json:"tracker_id,omitempty"`
+	// UserID is owner of tracker, used to find user's notification channels
+	UserID   int      `json:"user_id,omitempty"`
 	ChatID   int64    `json:"chat_id"`
 	Data     P2PItemI `json:"top_order"`
 	Exchange string   `json:"exchange"`
+	Asset    string   `json:"asset"`
 	Side     string   `json:"side"`
 	Currency string   `json:"currency"`
+	// Kind of tracker, notifications without kind are outbid notifications
+	Kind      string  `json:"kind,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+	Direction string  `json:"direction,omitempty"`
+	// Spread alert data
+	CompareExchange string  `json:"compare_exchange,omitempty"`
+	ComparePrice    float64 `json:"compare_price,omitempty"`
+	Spread          float64 `json:"spread,omitempty"`
+	// Arbitrage data, Data is advertisement on buy exchange(Exchange),
+	// compare price and name belong to advertisement on sell exchange(CompareExchange)
+	CompareName string   `json:"compare_name,omitempty"`
+	Methods     []string `json:"methods,omitempty"`
+	// Channels are channel kinds preferred by user, all channels if empty
+	Channels []string `json:"channels,omitempty"`
+	// Summary lines of notifications held during quiet hours
+	Summary []string `json:"summary,omitempty"`
+	// PaymentMethods are ids and names of payment methods of Data
+	PaymentMethods []PaymentMethod `json:"payment_methods,omitempty"`
+	// PreviousPrice is price of tracker before notification
+	PreviousPrice float64 `json:"previous_price,omitempty"`
+}
+
+// PaymentMethodNames returns names of payment methods of advertisement,
+// ids are returned when names weren't resolved
+func (n Notification) PaymentMethodNames() []string {
+	if len(n.PaymentMethods) == 0 {
+		if n.Data == nil {
+			return nil
+		}
+		return n.Data.GetPaymentMethods()
+	}
+	out := make([]string, 0, len(n.PaymentMethods))
+	for _, method := range n.PaymentMethods {
+		out = append(out, method.Name)
+	}
+	return out
+}
+
+// NotificationKindArbitrage is kind of notifications sent by arbitrage scanner
+const NotificationKindArbitrage = "arbitrage"
+
+// NotificationKindSummary is kind of notification with alerts held during quiet hours
+const NotificationKindSummary = "summary"
+
+// SummaryLine returns one line description of notification held during quiet hours
+func SummaryLine(n Notification, at time.Time) string {
+	kind := n.Kind
+	if kind == "" {
+		kind = models.TrackerKindOutbid
+	}
+	line := fmt.Sprintf("%s %s %s %s/%s %s", at.Format("15:04"), kind, n.Exchange, n.Asset, n.Currency, n.Side)
+	if n.Data != nil {
+		line += fmt.Sprintf(": %s %.2f%s", n.Data.GetName(), n.Data.GetPrice(), n.Currency)
+	}
+	return line
+}
+
+// Spread returns difference between prices in percents of the lower price
+func Spread(a, b float64) float64 {
+	low := math.Min(a, b)
+	if low <= 0 {
+		return 0
+	}
+	return math.Abs(a-b) / low * 100
 }
 
 func (n *Notification) UnmarshalJSON(data []byte) error {
@@ -26,22 +99,17 @@ func (n *Notification) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
+	// summary notifications have no advertisement
+	if len(aux.Data) == 0 || string(aux.Data) == "null" {
+		n.Data = nil
+		return nil
+	}
 
-	if aux.Exchange == "binance" {
-		var item DataItem
-		if err := json.Unmarshal(aux.Data, &item); err != nil {
-			return err
-		}
-		n.Data = item
-	} else if aux.Exchange == "bybit" {
-		var item Item
-		if err := json.Unmarshal(aux.Data, &item); err != nil {
-			return err
-		}
-		n.Data = item
-	} else {
-		return fmt.Errorf("no unmarshal logic for %s", aux.Exchange)
+	item, err := DecodeItem(aux.Exchange, aux.Data)
+	if err != nil {
+		return err
 	}
+	n.Data = item
 
 	return nil
 }
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventsExchange is topic exchange of user events, routing key is UserEventsKey
+const EventsExchange = "events"
+
+// Event types pushed to dashboard
+const (
+	// EventOutbid tracked advertisement was outbidded
+	EventOutbid = "outbid"
+	// EventOnTop tracked advertisement is the best one again
+	EventOnTop = "on_top"
+	// EventPriceChanged price of tracked advertisement changed
+	EventPriceChanged = "price_changed"
+	// EventNotification notification was sent to user
+	EventNotification = "notification"
+)
+
+// UserEventsKey returns routing key of user's events
+func UserEventsKey(userID int) string {
+	return fmt.Sprintf("user.%d", userID)
+}
+
+// UserEvent is tracker state transition or notification of user
+type UserEvent struct {
+	Type      string    `json:"type"`
+	TrackerID int64     `json:"tracker_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Payment method of not aggregated tracker
+	PaymentMethod string  `json:"payment_method,omitempty"`
+	Price         float64 `json:"price,omitempty"`
+	OldPrice      float64 `json:"old_price,omitempty"`
+	// Competitor which outbidded tracked advertisement
+	Competitor      string             `json:"competitor,omitempty"`
+	CompetitorPrice float64            `json:"competitor_price,omitempty"`
+	Notification    *NotificationEvent `json:"notification,omitempty"`
+}
+
+// NotificationUserEvent returns notification event for dashboard,
+// delivery details like telegram chat are not sent to browser
+func NotificationUserEvent(n Notification) UserEvent {
+	event := NewNotificationEvent(n)
+	event.UserID = 0
+	event.ChatID = 0
+	event.Channels = nil
+	return UserEvent{
+		Type:         EventNotification,
+		TrackerID:    n.TrackerID,
+		CreatedAt:    time.Now().UTC(),
+		Notification: &event,
+	}
+}
+
+/*
+TrackerStateEvents returns events of tracker state transition:
+outbid when advertisement became outbidded, on_top when it is not outbidded anymore,
+price_changed when price of tracked advertisement changed(0 new price means unknown)
+*/
+func TrackerStateEvents(trackerID int64, wasOutbid, outbid bool, oldPrice, newPrice float64) []UserEvent {
+	now := time.Now().UTC()
+	events := make([]UserEvent, 0)
+	if outbid && !wasOutbid {
+		events = append(events, UserEvent{Type: EventOutbid, TrackerID: trackerID, CreatedAt: now, Price: oldPrice})
+	}
+	if !outbid && wasOutbid {
+		events = append(events, UserEvent{Type: EventOnTop, TrackerID: trackerID, CreatedAt: now, Price: newPrice})
+	}
+	if newPrice != 0 && newPrice != oldPrice {
+		events = append(events, UserEvent{Type: EventPriceChanged, TrackerID: trackerID, CreatedAt: now,
+			Price: newPrice, OldPrice: oldPrice})
+	}
+	return events
+}
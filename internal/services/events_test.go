@@ -0,0 +1,59 @@
+package services
+
+import "testing"
+
+func TestTrackerStateEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		wasOutbid bool
+		outbid    bool
+		oldPrice  float64
+		newPrice  float64
+		want      []string
+	}{
+		{"still on top", false, false, 1.00, 1.00, []string{}},
+		{"outbidded", false, true, 1.00, 0, []string{EventOutbid}},
+		{"still outbidded", true, true, 1.00, 0, []string{}},
+		{"back on top", true, false, 1.00, 0, []string{EventOnTop}},
+		{"updated price", true, false, 1.00, 0.99, []string{EventOnTop, EventPriceChanged}},
+		{"price changed", false, false, 1.00, 1.01, []string{EventPriceChanged}},
+	}
+	for _, tt := range tests {
+		events := TrackerStateEvents(7, tt.wasOutbid, tt.outbid, tt.oldPrice, tt.newPrice)
+		if len(events) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %+v", tt.name, tt.want, events)
+			continue
+		}
+		for i, e := range events {
+			if e.Type != tt.want[i] || e.TrackerID != 7 {
+				t.Errorf("%s: expected %s event, got %+v", tt.name, tt.want[i], e)
+			}
+		}
+	}
+}
+
+func TestUserEventsKey(t *testing.T) {
+	if key := UserEventsKey(42); key != "user.42" {
+		t.Errorf("unexpected key %s", key)
+	}
+}
+
+func TestNotificationUserEvent(t *testing.T) {
+	e := NotificationUserEvent(Notification{
+		TrackerID: 7,
+		UserID:    3,
+		ChatID:    100,
+		Exchange:  "okx",
+		Channels:  []string{"telegram"},
+		Data:      OkxItem{NickName: "rival", Price: "1.01"},
+	})
+	if e.Type != EventNotification || e.TrackerID != 7 || e.Notification == nil {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e.Notification.ChatID != 0 || e.Notification.UserID != 0 || e.Notification.Channels != nil {
+		t.Errorf("delivery details must not be sent to dashboard: %+v", e.Notification)
+	}
+	if e.Notification.Ad == nil || e.Notification.Ad.Competitor != "rival" {
+		t.Errorf("unexpected advertisement %+v", e.Notification.Ad)
+	}
+}
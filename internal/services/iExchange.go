@@ -4,10 +4,10 @@ import "fmt"
 
 // ExchangeI is an interface for exchanges
 type ExchangeI interface {
-	GetBestAdv(currency, side string, paymentMethods []string) (P2PItemI, error)
+	GetBestAdv(asset, currency, side string, paymentMethods []string) (P2PItemI, error)
 	GetName() string
-	GetAds(currency, side string) ([]P2PItemI, error)
-	GetAdsByName(currency, side, username string, pMethods []string) ([]P2PItemI, error)
+	GetAds(asset, currency, side string) ([]P2PItemI, error)
+	GetAdsByName(asset, currency, side, username string, pMethods []string) ([]P2PItemI, error)
 	GetCachedPaymentMethods(curr string) ([]PaymentMethod, error)
 	GetCachedCurrencies() ([]string, error)
 }
@@ -18,6 +18,12 @@ type P2PItemI interface {
 	GetName() string
 	GetQuantity() (float64, float64, float64)
 	GetPaymentMethods() []string
+	// GetOrderCount returns number of advertiser's recent orders
+	GetOrderCount() int
+	// GetCompletionRate returns advertiser's order completion rate in percents
+	GetCompletionRate() float64
+	// ToAdvertisement maps exchange specific item to exchange independent advertisement
+	ToAdvertisement() Advertisement
 }
 
 // PaymentMethod is a struct for payment methods
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// topAdsCount is number of advertisements stored in each snapshot
+const topAdsCount = 10
+
+// maxHistoryBuckets limits size of history response
+const maxHistoryBuckets = 1000
+
+type MarketService struct {
+	repo *repository.MarketRepository
+}
+
+func NewMarketService(repo *repository.MarketRepository) *MarketService {
+	return &MarketService{repo: repo}
+}
+
+// NewSnapshot creates snapshot of advertisement book, ads must be sorted best first
+func NewSnapshot(key models.BookKey, ads []P2PItemI) (*models.MarketSnapshot, error) {
+	if len(ads) == 0 {
+		return nil, fmt.Errorf("book is empty")
+	}
+	snapshot := &models.MarketSnapshot{
+		BookKey:   key,
+		BestPrice: ads[0].GetPrice(),
+		AdsCount:  len(ads),
+	}
+	top := make([]models.TopAd, 0, topAdsCount)
+	for i, ad := range ads {
+		q, _, _ := ad.GetQuantity()
+		snapshot.Depth += q
+		if i < topAdsCount {
+			top = append(top, models.TopAd{
+				Name:     ad.GetName(),
+				Price:    ad.GetPrice(),
+				Quantity: q,
+				Payment:  ad.GetPaymentMethods(),
+			})
+		}
+	}
+	topJSON, err := json.Marshal(top)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.TopAds = topJSON
+	return snapshot, nil
+}
+
+// SaveSnapshot stores best price, depth and top advertisers of the book
+func (s *MarketService) SaveSnapshot(key models.BookKey, ads []P2PItemI) error {
+	snapshot, err := NewSnapshot(key, ads)
+	if err != nil {
+		return err
+	}
+	return s.repo.SaveSnapshot(snapshot)
+}
+
+/*
+ValidateHistoryQuery checks history time range
+
+return error if from is after to, interval is shorter than a minute
+or too many buckets requested
+*/
+func ValidateHistoryQuery(from, to time.Time, interval time.Duration) error {
+	if !from.Before(to) {
+		return fmt.Errorf("from must be before to")
+	}
+	if interval < time.Minute {
+		return fmt.Errorf("interval must be at least 1m")
+	}
+	if to.Sub(from)/interval > maxHistoryBuckets {
+		return fmt.Errorf("too many buckets, increase interval")
+	}
+	return nil
+}
+
+// GetHistory returns OHLC buckets of best price, query must be validated with ValidateHistoryQuery
+func (s *MarketService) GetHistory(key models.BookKey, from, to time.Time, interval time.Duration) ([]*models.MarketCandle, error) {
+	return s.repo.GetHistory(key, from, to, interval)
+}
+
+// ParseInterval parses duration like 15m, 4h or 1d
+func ParseInterval(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid interval %s", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid interval %s", s)
+	}
+	return d, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"encoding/json"
+	"p2pbot/internal/db/models"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	tests := map[string]time.Duration{
+		"15m": 15 * time.Minute,
+		"4h":  4 * time.Hour,
+		"1d":  24 * time.Hour,
+		"7d":  7 * 24 * time.Hour,
+	}
+	for in, want := range tests {
+		got, err := ParseInterval(in)
+		if err != nil || got != want {
+			t.Errorf("ParseInterval(%s) = %v, %v, want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "-1h", "0d", "week"} {
+		if _, err := ParseInterval(in); err == nil {
+			t.Errorf("expected error for %q", in)
+		}
+	}
+}
+
+func TestValidateHistoryQuery(t *testing.T) {
+	now := time.Now()
+	if err := ValidateHistoryQuery(now.Add(-time.Hour), now, time.Minute); err != nil {
+		t.Errorf("Error: %v", err)
+	}
+	if err := ValidateHistoryQuery(now, now.Add(-time.Hour), time.Minute); err == nil {
+		t.Error("expected error for reversed range")
+	}
+	if err := ValidateHistoryQuery(now.Add(-time.Hour), now, time.Second); err == nil {
+		t.Error("expected error for short interval")
+	}
+	if err := ValidateHistoryQuery(now.Add(-30*24*time.Hour), now, time.Minute); err == nil {
+		t.Error("expected error for too many buckets")
+	}
+}
+
+func TestNewSnapshot(t *testing.T) {
+	ads := make([]P2PItemI, 0)
+	for i := 0; i < 12; i++ {
+		ads = append(ads, Item{NickName: "trader", Price: "24.5", Quantity: "100"})
+	}
+	key := models.BookKey{Exchange: "bybit", Asset: "USDT", Currency: "CZK", Side: "SELL"}
+	s, err := NewSnapshot(key, ads)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s.BestPrice != 24.5 || s.Depth != 1200 || s.AdsCount != 12 {
+		t.Errorf("unexpected snapshot %+v", s)
+	}
+	var top []models.TopAd
+	if err := json.Unmarshal(s.TopAds, &top); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(top) != topAdsCount {
+		t.Errorf("expected %d top ads, got %d", topAdsCount, len(top))
+	}
+
+	if _, err := NewSnapshot(key, nil); err == nil {
+		t.Error("expected error for empty book")
+	}
+}
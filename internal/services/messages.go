@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"p2pbot/internal/db/models"
+	"strings"
+	"time"
+)
+
+// NotifyExchange is topic exchange of notifications and system messages,
+// routing keys are NotificationKey and SystemKey
+const NotifyExchange = "notify"
+
+/*
+EnvelopeVersion is schema version of published envelopes.
+
+Version 1 notification payload is Notification with exchange specific top_order,
+version 2 payload is NotificationEvent described by NotificationSchema
+*/
+const EnvelopeVersion = 2
+
+// Envelope types
+const (
+	// MessageNotification payload is NotificationEvent
+	MessageNotification = "notification"
+	// MessageSystem payload is SystemEvent
+	MessageSystem = "system"
+)
+
+// System events
+const (
+	SystemObserverStarted = "observer_started"
+)
+
+// NotificationKinds are notification kinds with own routing keys
+var NotificationKinds = []string{
+	models.TrackerKindOutbid,
+	models.TrackerKindPrice,
+	models.TrackerKindSpread,
+	NotificationKindArbitrage,
+	NotificationKindSummary,
+}
+
+// NotificationBindings are binding keys of consumers handling every notification
+var NotificationBindings = func() []string {
+	keys := make([]string, 0, len(NotificationKinds))
+	for _, kind := range NotificationKinds {
+		keys = append(keys, "notify."+kind+".#")
+	}
+	return keys
+}()
+
+// Envelope wraps every message published to NotifyExchange
+type Envelope struct {
+	Version   int             `json:"version"`
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+// SystemEvent is payload of system messages, like observer start
+type SystemEvent struct {
+	Service string `json:"service"`
+	Event   string `json:"event"`
+}
+
+// NewEnvelope wraps payload to envelope of current version
+func NewEnvelope(msgType string, payload any) ([]byte, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		Version:   EnvelopeVersion,
+		Type:      msgType,
+		ID:        NewEventID(),
+		CreatedAt: time.Now().UTC(),
+		Payload:   body,
+	})
+}
+
+// keyPart makes value usable as a word of routing key
+func keyPart(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, ".", "_"))
+	if s == "" {
+		return "any"
+	}
+	return s
+}
+
+// NotificationKey returns routing key notify.<kind>.<exchange>.<currency> of notification
+func NotificationKey(n Notification) string {
+	kind := n.Kind
+	if kind == "" {
+		kind = models.TrackerKindOutbid
+	}
+	return fmt.Sprintf("notify.%s.%s.%s", keyPart(kind), keyPart(n.Exchange), keyPart(n.Currency))
+}
+
+// SystemKey returns routing key notify.system.<event> of system message
+func SystemKey(event string) string {
+	return "notify.system." + keyPart(event)
+}
+
+// DecodeEnvelope parses envelope and checks that its version is supported
+func DecodeEnvelope(body []byte) (*Envelope, error) {
+	var e Envelope
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, err
+	}
+	if e.Version < 1 || e.Version > EnvelopeVersion {
+		return nil, fmt.Errorf("unsupported envelope version %d", e.Version)
+	}
+	return &e, nil
+}
+
+/*
+DecodeNotification returns notification of notification envelope.
+
+Envelopes of version 1 and notifications published before envelopes
+were introduced, which may still wait in durable queues, are decoded too,
+notifications published before assets support get DefaultAsset
+*/
+func DecodeNotification(body []byte) (Notification, error) {
+	n, err := decodeNotification(body)
+	n.Asset = NormalizeAsset(n.Asset)
+	return n, err
+}
+
+func decodeNotification(body []byte) (Notification, error) {
+	var n Notification
+	var probe struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return n, err
+	}
+	if probe.Version == nil {
+		err := json.Unmarshal(body, &n)
+		return n, err
+	}
+	e, err := DecodeEnvelope(body)
+	if err != nil {
+		return n, err
+	}
+	if e.Type != MessageNotification {
+		return n, fmt.Errorf("expected %s message, got %s", MessageNotification, e.Type)
+	}
+	if e.Version == 1 {
+		err = json.Unmarshal(e.Payload, &n)
+		return n, err
+	}
+	var event NotificationEvent
+	if err := json.Unmarshal(e.Payload, &event); err != nil {
+		return n, err
+	}
+	return event.Notification(), nil
+}
+
+// EncodeNotification wraps notification to envelope of current version
+func EncodeNotification(n Notification) ([]byte, error) {
+	return NewEnvelope(MessageNotification, NewNotificationEvent(n))
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNotificationKey(t *testing.T) {
+	tests := []struct {
+		n    Notification
+		want string
+	}{
+		{Notification{Exchange: "Binance", Currency: "EUR"}, "notify.outbid.binance.eur"},
+		{Notification{Kind: "spread", Exchange: "bybit", Currency: "USD"}, "notify.spread.bybit.usd"},
+		{Notification{Kind: NotificationKindSummary}, "notify.summary.any.any"},
+	}
+	for _, tt := range tests {
+		if key := NotificationKey(tt.n); key != tt.want {
+			t.Errorf("expected %s, got %s", tt.want, key)
+		}
+	}
+	if key := SystemKey(SystemObserverStarted); key != "notify.system.observer_started" {
+		t.Errorf("unexpected system key %s", key)
+	}
+}
+
+func TestNotificationBindings(t *testing.T) {
+	for _, kind := range NotificationKinds {
+		key := NotificationKey(Notification{Kind: kind, Exchange: "okx", Currency: "EUR"})
+		bound := false
+		for _, binding := range NotificationBindings {
+			if strings.HasPrefix(key, strings.TrimSuffix(binding, "#")) {
+				bound = true
+			}
+		}
+		if !bound {
+			t.Errorf("%s is not bound", key)
+		}
+	}
+	for _, binding := range NotificationBindings {
+		if strings.HasPrefix(SystemKey("any"), strings.TrimSuffix(binding, "#")) {
+			t.Errorf("system messages must not match %s", binding)
+		}
+	}
+}
+
+func TestDecodeNotification(t *testing.T) {
+	notification := Notification{
+		UserID:   3,
+		Exchange: "okx",
+		Currency: "EUR",
+		Data:     OkxItem{NickName: "rival", Price: "1.01", PaymentMethods: []string{"bank"}},
+	}
+	body, err := EncodeNotification(notification)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := DecodeNotification(body)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if n.UserID != 3 || n.Data == nil || n.Data.GetName() != "rival" || n.Data.GetPrice() != 1.01 {
+		t.Errorf("unexpected notification %+v", n)
+	}
+
+	// version 1 payload is notification with exchange specific advertisement
+	payload, _ := json.Marshal(notification)
+	v1, _ := json.Marshal(Envelope{Version: 1, Type: MessageNotification, Payload: payload})
+	n, err = DecodeNotification(v1)
+	if err != nil {
+		t.Fatalf("Error decoding version 1: %v", err)
+	}
+	if _, ok := n.Data.(OkxItem); !ok || n.Data.GetName() != "rival" {
+		t.Errorf("unexpected version 1 notification %+v", n)
+	}
+
+	// notifications published before envelopes
+	n, err = DecodeNotification(payload)
+	if err != nil {
+		t.Fatalf("Error decoding legacy notification: %v", err)
+	}
+	if n.UserID != 3 || n.Data == nil || n.Data.GetName() != "rival" {
+		t.Errorf("unexpected legacy notification %+v", n)
+	}
+	// published before assets support
+	n, err = DecodeNotification([]byte(`{"user_id":3,"exchange":"okx","currency":"EUR"}`))
+	if err != nil || n.Asset != DefaultAsset {
+		t.Errorf("expected %s notification, got %q, %v", DefaultAsset, n.Asset, err)
+	}
+	if e := NewNotificationEvent(n); e.Asset != DefaultAsset {
+		t.Errorf("expected %s event, got %q", DefaultAsset, e.Asset)
+	}
+	if e := NewNotificationEvent(Notification{Currency: "EUR"}); e.Asset != DefaultAsset {
+		t.Errorf("expected %s event of notification without asset, got %q", DefaultAsset, e.Asset)
+	}
+
+	system, err := NewEnvelope(MessageSystem, SystemEvent{Service: "observer", Event: SystemObserverStarted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeNotification(system); err == nil {
+		t.Error("expected error for system message")
+	}
+
+	future, _ := json.Marshal(Envelope{Version: EnvelopeVersion + 1, Type: MessageNotification})
+	if _, err := DecodeNotification(future); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
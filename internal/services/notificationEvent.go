@@ -0,0 +1,202 @@
+package services
+
+import (
+	_ "embed"
+	"p2pbot/internal/db/models"
+)
+
+/*
+NotificationSchema is JSON schema of notification envelope of version 2,
+it is served by API so consumers outside of the project can validate messages
+*/
+//go:embed schema/notification.v2.json
+var NotificationSchema []byte
+
+/*
+NotificationEvent is payload of notification envelopes since version 2.
+
+Unlike Notification it doesn't depend on exchange specific advertisement
+format, so consumers don't need exchange adapters to decode it
+*/
+type NotificationEvent struct {
+	// NotificationID is id of notification delivery log record
+	NotificationID int64    `json:"notification_id,omitempty"`
+	Kind           string   `json:"kind"`
+	TrackerID      int64    `json:"tracker_id,omitempty"`
+	UserID         int      `json:"user_id,omitempty"`
+	ChatID         int64    `json:"chat_id,omitempty"`
+	Exchange       string   `json:"exchange"`
+	Asset          string   `json:"asset"`
+	Fiat           string   `json:"fiat"`
+	Side           string   `json:"side,omitempty"`
+	Ad             *EventAd `json:"ad,omitempty"`
+	// PreviousPrice is price of tracker before notification
+	PreviousPrice   float64  `json:"previous_price,omitempty"`
+	Threshold       float64  `json:"threshold,omitempty"`
+	Direction       string   `json:"direction,omitempty"`
+	CompareExchange string   `json:"compare_exchange,omitempty"`
+	ComparePrice    float64  `json:"compare_price,omitempty"`
+	CompareName     string   `json:"compare_name,omitempty"`
+	Spread          float64  `json:"spread,omitempty"`
+	Methods         []string `json:"methods,omitempty"`
+	Channels        []string `json:"channels,omitempty"`
+	Summary         []string `json:"summary,omitempty"`
+}
+
+// EventAd is normalized advertisement of competitor
+type EventAd struct {
+	// ID of advertisement on exchange
+	ID         string `json:"id,omitempty"`
+	Competitor string `json:"competitor"`
+	// CompetitorID is id of advertiser on exchange
+	CompetitorID   string          `json:"competitor_id,omitempty"`
+	IsMerchant     bool            `json:"is_merchant"`
+	Price          float64         `json:"price"`
+	Quantity       float64         `json:"quantity"`
+	MinAmount      float64         `json:"min_amount"`
+	MaxAmount      float64         `json:"max_amount"`
+	PaymentMethods []PaymentMethod `json:"payment_methods"`
+	OrderCount     int             `json:"order_count"`
+	CompletionRate float64         `json:"completion_rate"`
+	PositiveRate   float64         `json:"positive_rate,omitempty"`
+	// PaymentTimeLimit is minutes buyer has to pay
+	PaymentTimeLimit int    `json:"payment_time_limit,omitempty"`
+	Remarks          string `json:"remarks,omitempty"`
+}
+
+func (a EventAd) GetPrice() float64 {
+	return a.Price
+}
+
+func (a EventAd) GetName() string {
+	return a.Competitor
+}
+
+func (a EventAd) GetQuantity() (float64, float64, float64) {
+	return a.Quantity, a.MinAmount, a.MaxAmount
+}
+
+// GetPaymentMethods returns payment method ids like advertisements of exchanges
+func (a EventAd) GetPaymentMethods() []string {
+	out := make([]string, 0, len(a.PaymentMethods))
+	for _, method := range a.PaymentMethods {
+		out = append(out, method.Id)
+	}
+	return out
+}
+
+func (a EventAd) GetOrderCount() int {
+	return a.OrderCount
+}
+
+func (a EventAd) GetCompletionRate() float64 {
+	return a.CompletionRate
+}
+
+func (a EventAd) ToAdvertisement() Advertisement {
+	return Advertisement{
+		ID: a.ID,
+		Advertiser: AdvertiserInfo{
+			ID:             a.CompetitorID,
+			Nickname:       a.Competitor,
+			OrderCount:     a.OrderCount,
+			CompletionRate: a.CompletionRate,
+			PositiveRate:   a.PositiveRate,
+			IsMerchant:     a.IsMerchant,
+		},
+		Price:            a.Price,
+		Quantity:         a.Quantity,
+		MinAmount:        a.MinAmount,
+		MaxAmount:        a.MaxAmount,
+		PaymentMethods:   a.GetPaymentMethods(),
+		PaymentTimeLimit: a.PaymentTimeLimit,
+		Remarks:          a.Remarks,
+	}
+}
+
+// NewNotificationEvent converts notification to exchange independent event
+func NewNotificationEvent(n Notification) NotificationEvent {
+	e := NotificationEvent{
+		NotificationID:  n.ID,
+		Kind:            n.Kind,
+		TrackerID:       n.TrackerID,
+		UserID:          n.UserID,
+		ChatID:          n.ChatID,
+		Exchange:        n.Exchange,
+		Asset:           NormalizeAsset(n.Asset),
+		Fiat:            n.Currency,
+		Side:            n.Side,
+		PreviousPrice:   n.PreviousPrice,
+		Threshold:       n.Threshold,
+		Direction:       n.Direction,
+		CompareExchange: n.CompareExchange,
+		ComparePrice:    n.ComparePrice,
+		CompareName:     n.CompareName,
+		Spread:          n.Spread,
+		Methods:         n.Methods,
+		Channels:        n.Channels,
+		Summary:         n.Summary,
+	}
+	if e.Kind == "" {
+		e.Kind = models.TrackerKindOutbid
+	}
+	if n.Data == nil {
+		return e
+	}
+	adv := n.Data.ToAdvertisement()
+	ad := &EventAd{
+		ID:               adv.ID,
+		Competitor:       adv.Advertiser.Nickname,
+		CompetitorID:     adv.Advertiser.ID,
+		IsMerchant:       adv.Advertiser.IsMerchant,
+		Price:            adv.Price,
+		Quantity:         adv.Quantity,
+		MinAmount:        adv.MinAmount,
+		MaxAmount:        adv.MaxAmount,
+		PaymentMethods:   n.PaymentMethods,
+		OrderCount:       adv.Advertiser.OrderCount,
+		CompletionRate:   adv.Advertiser.CompletionRate,
+		PositiveRate:     adv.Advertiser.PositiveRate,
+		PaymentTimeLimit: adv.PaymentTimeLimit,
+		Remarks:          adv.Remarks,
+	}
+	// names weren't resolved, ids are the best names known
+	if len(ad.PaymentMethods) == 0 {
+		ad.PaymentMethods = make([]PaymentMethod, 0)
+		for _, id := range adv.PaymentMethods {
+			ad.PaymentMethods = append(ad.PaymentMethods, PaymentMethod{Id: id, Name: id})
+		}
+	}
+	e.Ad = ad
+	return e
+}
+
+// Notification converts event back to notification, Data is EventAd
+func (e NotificationEvent) Notification() Notification {
+	n := Notification{
+		ID:              e.NotificationID,
+		Kind:            e.Kind,
+		TrackerID:       e.TrackerID,
+		UserID:          e.UserID,
+		ChatID:          e.ChatID,
+		Exchange:        e.Exchange,
+		Asset:           e.Asset,
+		Currency:        e.Fiat,
+		Side:            e.Side,
+		PreviousPrice:   e.PreviousPrice,
+		Threshold:       e.Threshold,
+		Direction:       e.Direction,
+		CompareExchange: e.CompareExchange,
+		ComparePrice:    e.ComparePrice,
+		CompareName:     e.CompareName,
+		Spread:          e.Spread,
+		Methods:         e.Methods,
+		Channels:        e.Channels,
+		Summary:         e.Summary,
+	}
+	if e.Ad != nil {
+		n.Data = *e.Ad
+		n.PaymentMethods = e.Ad.PaymentMethods
+	}
+	return n
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNotificationEvent(t *testing.T) {
+	n := Notification{
+		ID:            7,
+		TrackerID:     2,
+		UserID:        3,
+		Exchange:      "okx",
+		Asset:         "USDT",
+		Side:          "SELL",
+		Currency:      "EUR",
+		PreviousPrice: 1.02,
+		Data: OkxItem{NickName: "rival", Price: "1.01", AvailableAmount: "500",
+			QuoteMinAmountPerOrder: "10", QuoteMaxAmountPerOrder: "400", PaymentMethods: []string{"bank", "revolut"}},
+		PaymentMethods: []PaymentMethod{{Id: "bank", Name: "Bank Transfer"}, {Id: "revolut", Name: "Revolut"}},
+	}
+	e := NewNotificationEvent(n)
+	if e.Kind != "outbid" || e.Fiat != "EUR" || e.PreviousPrice != 1.02 || e.Ad == nil {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if e.Ad.Competitor != "rival" || e.Ad.Price != 1.01 || e.Ad.Quantity != 500 ||
+		e.Ad.MinAmount != 10 || e.Ad.MaxAmount != 400 || e.Ad.PaymentMethods[0].Name != "Bank Transfer" {
+		t.Errorf("unexpected advertisement %+v", e.Ad)
+	}
+
+	back := e.Notification()
+	if back.ID != 7 || back.Currency != "EUR" || back.Data.GetName() != "rival" {
+		t.Errorf("unexpected notification %+v", back)
+	}
+	if methods := back.Data.GetPaymentMethods(); len(methods) != 2 || methods[1] != "revolut" {
+		t.Errorf("expected payment method ids, got %v", methods)
+	}
+	if names := back.PaymentMethodNames(); len(names) != 2 || names[0] != "Bank Transfer" {
+		t.Errorf("expected payment method names, got %v", names)
+	}
+
+	// ids are names of unresolved methods
+	n.PaymentMethods = nil
+	e = NewNotificationEvent(n)
+	if e.Ad.PaymentMethods[0] != (PaymentMethod{Id: "bank", Name: "bank"}) {
+		t.Errorf("unexpected payment methods %v", e.Ad.PaymentMethods)
+	}
+
+	summary := NewNotificationEvent(Notification{Kind: NotificationKindSummary, Summary: []string{"line"}})
+	if summary.Ad != nil || summary.Notification().Data != nil {
+		t.Error("summary must have no advertisement")
+	}
+}
+
+// TestNotificationSchema checks that published schema describes every field of event
+func TestNotificationSchema(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Defs       map[string]struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(NotificationSchema, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+
+	body, err := EncodeNotification(Notification{
+		ID:              1,
+		TrackerID:       2,
+		UserID:          3,
+		ChatID:          4,
+		Exchange:        "okx",
+		Asset:           "USDT",
+		Side:            "BUY",
+		Currency:        "EUR",
+		Kind:            NotificationKindArbitrage,
+		Threshold:       1,
+		Direction:       "above",
+		CompareExchange: "bybit",
+		ComparePrice:    1.1,
+		CompareName:     "seller",
+		Spread:          2,
+		Methods:         []string{"bank"},
+		Channels:        []string{"email"},
+		Summary:         []string{"line"},
+		PreviousPrice:   1,
+		Data:            OkxItem{NickName: "rival", Price: "1.01", PaymentMethods: []string{"bank"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope map[string]json.RawMessage
+	json.Unmarshal(body, &envelope)
+	for key := range envelope {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("envelope field %s is not in schema", key)
+		}
+	}
+
+	var event, ad map[string]json.RawMessage
+	json.Unmarshal(envelope["payload"], &event)
+	json.Unmarshal(event["ad"], &ad)
+	for def, fields := range map[string]map[string]json.RawMessage{"event": event, "ad": ad} {
+		for key := range fields {
+			if _, ok := schema.Defs[def].Properties[key]; !ok {
+				t.Errorf("%s field %s is not in schema", def, key)
+			}
+		}
+		for _, key := range schema.Defs[def].Required {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("required %s field %s is missing", def, key)
+			}
+		}
+	}
+}
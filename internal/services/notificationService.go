@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"slices"
+)
+
+// maxNotificationsPage limits size of notification history page
+const maxNotificationsPage = 100
+
+type NotificationService struct {
+	repo *repository.NotificationRepository
+}
+
+func NewNotificationService(repo *repository.NotificationRepository) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// NewNotificationRecord converts notification to delivery log record of user
+func NewNotificationRecord(userID int, n Notification, status string) *models.Notification {
+	record := &models.Notification{
+		UserID:   userID,
+		Kind:     n.Kind,
+		Exchange: n.Exchange,
+		Asset:    n.Asset,
+		Currency: n.Currency,
+		Side:     n.Side,
+		Status:   status,
+		Payment:  make([]string, 0),
+	}
+	if record.Kind == "" {
+		record.Kind = models.TrackerKindOutbid
+	}
+	if n.TrackerID != 0 {
+		record.TrackerID = &n.TrackerID
+	}
+	if n.Data != nil {
+		record.Competitor = n.Data.GetName()
+		record.Price = n.Data.GetPrice()
+		record.Payment = n.Data.GetPaymentMethods()
+	}
+	return record
+}
+
+// Log saves notification to delivery log and sets its ID
+func (s *NotificationService) Log(userID int, n *Notification, status string) error {
+	record := NewNotificationRecord(userID, *n, status)
+	if err := s.repo.Save(record); err != nil {
+		return err
+	}
+	n.ID = record.ID
+	return nil
+}
+
+// MarkSent stores telegram message id of delivered notification
+func (s *NotificationService) MarkSent(id int64, messageID int) error {
+	return s.repo.UpdateStatus(id, models.NotificationSent, int64(messageID), "")
+}
+
+// MarkFailed stores delivery error
+func (s *NotificationService) MarkFailed(id int64, reason error) error {
+	return s.repo.UpdateStatus(id, models.NotificationFailed, 0, reason.Error())
+}
+
+/*
+ValidateNotificationFilter checks history filter
+
+return error if status or kind is unknown, time range is reversed,
+limit is not between 1 and 100 or offset is negative
+*/
+func ValidateNotificationFilter(f models.NotificationFilter) error {
+	statuses := []string{models.NotificationPending, models.NotificationSent,
+		models.NotificationFailed, models.NotificationLimited,
+		models.NotificationQueued, models.NotificationThrottled}
+	if f.Status != "" && !slices.Contains(statuses, f.Status) {
+		return fmt.Errorf("status must be one of %v", statuses)
+	}
+	kinds := []string{models.TrackerKindOutbid, models.TrackerKindPrice,
+		models.TrackerKindSpread, NotificationKindArbitrage, NotificationKindSummary}
+	if f.Kind != "" && !slices.Contains(kinds, f.Kind) {
+		return fmt.Errorf("kind must be one of %v", kinds)
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		return fmt.Errorf("from must be before to")
+	}
+	if f.Limit < 1 || f.Limit > maxNotificationsPage {
+		return fmt.Errorf("limit must be between 1 and %d", maxNotificationsPage)
+	}
+	if f.Offset < 0 {
+		return fmt.Errorf("offset must not be negative")
+	}
+	return nil
+}
+
+// GetHistory returns page of user's notifications and total number matching filter
+func (s *NotificationService) GetHistory(userID int, f models.NotificationFilter) ([]*models.Notification, int, error) {
+	return s.repo.GetByUserID(userID, f)
+}
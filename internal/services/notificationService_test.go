@@ -0,0 +1,61 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+	"time"
+)
+
+func TestNewNotificationRecord(t *testing.T) {
+	n := Notification{
+		TrackerID: 7,
+		Data:      OkxItem{NickName: "rival", Price: "0.95", PaymentMethods: []string{"SEPA"}},
+		Exchange:  "okx",
+		Asset:     "USDT",
+		Side:      "BUY",
+		Currency:  "EUR",
+	}
+	record := NewNotificationRecord(3, n, models.NotificationPending)
+	if record.UserID != 3 || record.TrackerID == nil || *record.TrackerID != 7 {
+		t.Errorf("unexpected ids %+v", record)
+	}
+	if record.Kind != models.TrackerKindOutbid {
+		t.Errorf("expected outbid kind, got %s", record.Kind)
+	}
+	if record.Competitor != "rival" || record.Price != 0.95 || len(record.Payment) != 1 {
+		t.Errorf("unexpected competitor data %+v", record)
+	}
+
+	// Arbitrage notifications have no tracker
+	n.TrackerID = 0
+	n.Kind = NotificationKindArbitrage
+	if record := NewNotificationRecord(3, n, models.NotificationLimited); record.TrackerID != nil {
+		t.Errorf("expected no tracker, got %d", *record.TrackerID)
+	}
+}
+
+func TestValidateNotificationFilter(t *testing.T) {
+	now := time.Now()
+	valid := []models.NotificationFilter{
+		{Limit: 10},
+		{Limit: 100, Offset: 200, Status: "sent", Kind: "arbitrage", From: now.Add(-time.Hour), To: now},
+	}
+	for _, f := range valid {
+		if err := ValidateNotificationFilter(f); err != nil {
+			t.Errorf("expected valid filter %+v, got %v", f, err)
+		}
+	}
+	invalid := []models.NotificationFilter{
+		{Limit: 0},
+		{Limit: 101},
+		{Limit: 10, Offset: -10},
+		{Limit: 10, Status: "read"},
+		{Limit: 10, Kind: "volume"},
+		{Limit: 10, From: now, To: now.Add(-time.Hour)},
+	}
+	for _, f := range invalid {
+		if err := ValidateNotificationFilter(f); err == nil {
+			t.Errorf("expected error for %+v", f)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"p2pbot/internal/config"
+	"p2pbot/internal/rediscl"
+	"p2pbot/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+)
+
+type OkxExchange struct {
+	baseURL    string
+	name       string
+	maxRetries int
+	retryDelay time.Duration
+}
+
+type OkxAdsResponse struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+	Data struct {
+		Buy  []OkxItem `json:"buy"`
+		Sell []OkxItem `json:"sell"`
+	} `json:"data"`
+}
+
+// Implements P2PItem interface
+type OkxItem struct {
+	ID                     string   `json:"id"`
+	NickName               string   `json:"nickName"`
+	Price                  string   `json:"price"`
+	AvailableAmount        string   `json:"availableAmount"`
+	QuoteMinAmountPerOrder string   `json:"quoteMinAmountPerOrder"`
+	QuoteMaxAmountPerOrder string   `json:"quoteMaxAmountPerOrder"`
+	PaymentMethods         []string `json:"paymentMethods"`
+	CompletedOrderQuantity int      `json:"completedOrderQuantity"`
+	CompletedRate          string   `json:"completedRate"`
+	Side                   string   `json:"side"`
+	MerchantID             string   `json:"merchantId"`
+	// CreatorType is "certified" for verified merchants
+	CreatorType string `json:"creatorType"`
+}
+
+func init() {
+	RegisterExchange("okx", func(cfg *config.Config) ExchangeI {
+		return NewOkxExchange(cfg)
+	}, jsonItemDecoder[OkxItem]())
+}
+
+func NewOkxExchange(config *config.Config) *OkxExchange {
+	return &OkxExchange{
+		baseURL:    "https://www.okx.com",
+		name:       "OKX",
+		maxRetries: config.Exchange.MaxRetries,
+		retryDelay: time.Second * time.Duration(config.Exchange.RetryDelay),
+	}
+}
+
+func (ex OkxExchange) GetName() string {
+	return ex.name
+}
+
+// get returns body of successful response, body is closed before return
+func (ex OkxExchange) get(endpoint string) ([]byte, error) {
+	resp, err := http.Get(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// requestBook returns the whole OKX ad book for a given asset, currency and side.
+// OKX returns all ads in one response, so there is no pagination.
+func (ex OkxExchange) requestBook(asset, currency, side string) ([]OkxItem, error) {
+	if side != "BUY" && side != "SELL" {
+		return nil, fmt.Errorf("invalid side %s", side)
+	}
+
+	params := url.Values{}
+	params.Set("quoteCurrency", strings.ToLower(currency))
+	params.Set("baseCurrency", strings.ToLower(asset))
+	params.Set("side", strings.ToLower(side))
+	params.Set("paymentMethod", "all")
+	params.Set("userType", "all")
+	params.Set("showTrade", "false")
+	params.Set("showFollow", "false")
+	params.Set("showAlreadyTraded", "false")
+	params.Set("isAbleFilter", "false")
+	params.Set("receivingAds", "false")
+	params.Set("urlId", "0")
+	endpoint := ex.baseURL + "/v3/c2c/tradingOrders/books?" + params.Encode()
+
+	var body []byte
+	var err error
+	for attempt := 1; attempt <= ex.maxRetries; attempt++ {
+		body, err = ex.get(endpoint)
+		if err == nil {
+			break
+		}
+		if attempt == ex.maxRetries {
+			return nil, fmt.Errorf("could not get okx advertisements: %v, after %d attempts", err, ex.maxRetries)
+		}
+		log.Printf("could not get okx advertisements: %v, retrying...", err)
+		time.Sleep(ex.retryDelay)
+	}
+
+	okxResponse := OkxAdsResponse{}
+	if err := json.Unmarshal(body, &okxResponse); err != nil {
+		return nil, fmt.Errorf("could not parse response: %w", err)
+	}
+
+	if okxResponse.Code != 0 {
+		return nil, fmt.Errorf("okx error: %s", okxResponse.Msg)
+	}
+
+	if side == "BUY" {
+		return okxResponse.Data.Buy, nil
+	}
+	return okxResponse.Data.Sell, nil
+}
+
+// filterByMethods returns ads that accept at least one of given payment methods,
+// all ads are returned if no payment methods provided
+func filterByMethods(items []OkxItem, pMethods []string) []OkxItem {
+	if len(pMethods) == 0 {
+		return items
+	}
+	out := make([]OkxItem, 0)
+	for _, item := range items {
+		for _, method := range pMethods {
+			if utils.Contains(item.PaymentMethods, method) {
+				out = append(out, item)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (ex OkxExchange) GetBestAdv(asset, currency, side string, paymentMethods []string) (P2PItemI, error) {
+	items, err := ex.requestBook(asset, currency, side)
+	if err != nil {
+		return nil, err
+	}
+
+	items = filterByMethods(items, paymentMethods)
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no items found")
+	}
+
+	return items[0], nil
+}
+
+func (ex OkxExchange) GetAdsByName(asset, currency, side, username string, pMethods []string) ([]P2PItemI, error) {
+	items, err := ex.requestBook(asset, currency, side)
+	if err != nil {
+		return nil, fmt.Errorf("could not find advertisement with username %s", username)
+	}
+
+	out := make([]P2PItemI, 0)
+	for _, item := range filterByMethods(items, pMethods) {
+		if item.GetName() == username {
+			out = append(out, item)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("could not find advertisement with username %s", username)
+	}
+	return out, nil
+}
+
+// GetAds returns all advertisements for a given asset, currency and side
+func (ex OkxExchange) GetAds(asset, currency, side string) ([]P2PItemI, error) {
+	start := time.Now()
+	items, err := ex.requestBook(asset, currency, side)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting advertisements %v", err)
+	}
+	log.Debug().
+		Str("asset", asset).
+		Str("currency", currency).
+		Str("side", side).
+		Int("len(ads)", len(items)).
+		TimeDiff("request time(ms)", time.Now(), start).Msg("Fetching okx advertisements")
+
+	out := make([]P2PItemI, 0, len(items))
+	for _, item := range items {
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func (i OkxItem) GetName() string {
+	return i.NickName
+}
+
+func (i OkxItem) GetPrice() float64 {
+	price, _ := strconv.ParseFloat(i.Price, 64)
+	return price
+}
+
+func (i OkxItem) GetQuantity() (quantity, minAmount, maxAmount float64) {
+	quantity, _ = strconv.ParseFloat(i.AvailableAmount, 64)
+	minAmount, _ = strconv.ParseFloat(i.QuoteMinAmountPerOrder, 64)
+	maxAmount, _ = strconv.ParseFloat(i.QuoteMaxAmountPerOrder, 64)
+	return
+}
+
+func (i OkxItem) GetOrderCount() int {
+	return i.CompletedOrderQuantity
+}
+
+// GetCompletionRate converts okx completed rate("0.9912") to percents
+func (i OkxItem) GetCompletionRate() float64 {
+	rate, _ := strconv.ParseFloat(i.CompletedRate, 64)
+	return rate * 100
+}
+
+func (i OkxItem) GetPaymentMethods() []string {
+	return i.PaymentMethods
+}
+
+// ToAdvertisement maps okx item, okx doesn't return positive rate,
+// payment time limit and remarks in advertisement list
+func (i OkxItem) ToAdvertisement() Advertisement {
+	q, minA, maxA := i.GetQuantity()
+	return Advertisement{
+		ID: i.ID,
+		Advertiser: AdvertiserInfo{
+			ID:             i.MerchantID,
+			Nickname:       i.NickName,
+			OrderCount:     i.CompletedOrderQuantity,
+			CompletionRate: i.GetCompletionRate(),
+			IsMerchant:     i.CreatorType == "certified",
+		},
+		Price:          i.GetPrice(),
+		Quantity:       q,
+		MinAmount:      minA,
+		MaxAmount:      maxA,
+		PaymentMethods: i.PaymentMethods,
+	}
+}
+
+func (ex OkxExchange) FetchCurrencies() ([]string, error) {
+	resp, err := http.Get(ex.baseURL + "/v3/c2c/currency/fiat/list")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var okxFiatListResponse struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Currency string `json:"currency"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &okxFiatListResponse); err != nil {
+		return nil, fmt.Errorf("could not parse response: %w", err)
+	}
+
+	if okxFiatListResponse.Code != 0 {
+		return nil, fmt.Errorf("okx error: %s", okxFiatListResponse.Msg)
+	}
+
+	out := make([]string, 0)
+	for _, currency := range okxFiatListResponse.Data {
+		out = append(out, strings.ToUpper(currency.Currency))
+	}
+	return out, nil
+}
+
+func (ex OkxExchange) FetchPaymentMethods(currencies []string) (map[string][]PaymentMethod, error) {
+	out := make(map[string][]PaymentMethod)
+	for _, currency := range currencies {
+		resp, err := http.Get(ex.baseURL + "/v3/c2c/configs/receipt/templates?quoteCurrency=" +
+			url.QueryEscape(strings.ToLower(currency)))
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		var okxPaymentMethodsResponse struct {
+			Code int    `json:"code"`
+			Msg  string `json:"msg"`
+			Data []struct {
+				PaymentMethod string `json:"paymentMethod"`
+				Description   string `json:"paymentMethodDescription"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(body, &okxPaymentMethodsResponse); err != nil {
+			return nil, fmt.Errorf("could not parse response: %w", err)
+		}
+
+		if okxPaymentMethodsResponse.Code != 0 {
+			return nil, fmt.Errorf("okx error: %s", okxPaymentMethodsResponse.Msg)
+		}
+
+		methodsList := make([]PaymentMethod, 0)
+		for _, method := range okxPaymentMethodsResponse.Data {
+			methodsList = append(methodsList, PaymentMethod{
+				Id:   method.PaymentMethod,
+				Name: method.Description,
+			})
+		}
+		out[currency] = methodsList
+	}
+	return out, nil
+}
+
+func (ex OkxExchange) GetCachedPaymentMethods(curr string) ([]PaymentMethod, error) {
+	ctx := rediscl.RDB.Ctx
+	// Retrieve from cache
+	var err error
+	var currenciesJSON string
+
+	if curr == "" {
+		// Retrieve all
+		currenciesJSON, err = rediscl.RDB.Client.JSONGet(ctx, "okx:currencies", "$").Result()
+	} else {
+		// Retrieve specific currency
+		currenciesJSON, err = rediscl.RDB.Client.JSONGet(ctx, "okx:currencies",
+			fmt.Sprintf("$.%s", curr)).Result()
+	}
+
+	if currenciesJSON == "[]" {
+		return nil, fmt.Errorf("currency not found")
+	}
+
+	if err == redis.Nil || currenciesJSON == "" {
+		// Cache miss
+		currencies, err := ex.FetchCurrencies()
+		if err != nil {
+			return nil, err
+		}
+		methods, err := ex.FetchPaymentMethods(currencies)
+		if err != nil {
+			return nil, err
+		}
+		jsonMethods, err := json.Marshal(methods)
+		if err != nil {
+			return nil, err
+		}
+		// Cache the result
+		if err := rediscl.RDB.Client.JSONSet(ctx, "okx:currencies", "$", string(jsonMethods)).Err(); err != nil {
+			return nil, err
+		}
+		// Set expiration
+		if err := rediscl.RDB.Client.Expire(ctx, "okx:currencies", 24*time.Hour).Err(); err != nil {
+			return nil, err
+		}
+		return methods[curr], nil
+	}
+
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	// trim [] from string
+	currenciesJSON = currenciesJSON[1 : len(currenciesJSON)-1]
+	paymentMethods := make([]PaymentMethod, 0)
+	if err := json.Unmarshal([]byte(currenciesJSON), &paymentMethods); err != nil {
+		return nil, err
+	}
+
+	return paymentMethods, nil
+}
+
+func (ex *OkxExchange) GetCachedCurrencies() ([]string, error) {
+	ctx := rediscl.RDB.Ctx
+	// Retrieve from cache
+	currencies, err := rediscl.RDB.Client.SMembers(ctx, "okx:currencies_list").Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(currencies) == 0 {
+		// Cache miss
+		currencies, err := ex.FetchCurrencies()
+		if err != nil {
+			return nil, err
+		}
+		// Cache the result
+		for _, item := range currencies {
+			err := rediscl.RDB.Client.SAdd(ctx, "okx:currencies_list", item).Err()
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Set expiration
+		if err := rediscl.RDB.Client.Expire(ctx, "okx:currencies_list", 24*time.Hour).Err(); err != nil {
+			return nil, err
+		}
+		return currencies, nil
+	}
+
+	return currencies, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"embed"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// okxFixtures are embedded, TestMain changes working directory to project root
+//
+//go:embed testdata/okx
+var okxFixtures embed.FS
+
+// newOkxFixtureServer serves recorded OKX responses from testdata/okx
+func newOkxFixtureServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	serveFixture := func(w http.ResponseWriter, name string) {
+		data, err := okxFixtures.ReadFile("testdata/okx/" + name)
+		if err != nil {
+			// t.Fatalf must not be called from server goroutine
+			t.Errorf("could not read fixture %s: %v", name, err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v3/c2c/tradingOrders/books", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		switch q.Get("quoteCurrency") + q.Get("baseCurrency") {
+		case "eurusdt":
+			serveFixture(w, "books_eur_buy.json")
+		default:
+			serveFixture(w, "books_error.json")
+		}
+	})
+	mux.HandleFunc("/v3/c2c/currency/fiat/list", func(w http.ResponseWriter, r *http.Request) {
+		serveFixture(w, "fiat_list.json")
+	})
+	mux.HandleFunc("/v3/c2c/configs/receipt/templates", func(w http.ResponseWriter, r *http.Request) {
+		serveFixture(w, "payment_methods_"+r.URL.Query().Get("quoteCurrency")+".json")
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newOkxTestExchange(t *testing.T) *OkxExchange {
+	srv := newOkxFixtureServer(t)
+	return &OkxExchange{
+		baseURL:    srv.URL,
+		name:       "OKX",
+		maxRetries: 1,
+	}
+}
+
+func TestOkxGetAds(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	ads, err := okx.GetAds("USDT", "EUR", "BUY")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(ads) != 3 {
+		t.Fatalf("expected 3 ads, got %d", len(ads))
+	}
+	if ads[0].GetName() != "EuroDesk" || ads[0].GetPrice() != 0.95 {
+		t.Errorf("unexpected best ad %s %f", ads[0].GetName(), ads[0].GetPrice())
+	}
+	q, minA, maxA := ads[0].GetQuantity()
+	if q != 12500.50 || minA != 100 || maxA != 5000 {
+		t.Errorf("unexpected quantity %f %f %f", q, minA, maxA)
+	}
+
+	ads, err = okx.GetAds("USDT", "EUR", "SELL")
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(ads) != 0 {
+		t.Errorf("expected empty sell book, got %d ads", len(ads))
+	}
+}
+
+func TestOkxGetAdsInvalidSide(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	if _, err := okx.GetAds("USDT", "EUR", "HOLD"); err == nil {
+		t.Error("expected error for invalid side")
+	}
+}
+
+func TestOkxErrorResponse(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	if _, err := okx.GetAds("USDT", "CZK", "BUY"); err == nil {
+		t.Error("expected error for okx error code")
+	}
+	// Only USDT book is recorded
+	if _, err := okx.GetAds("BTC", "EUR", "BUY"); err == nil {
+		t.Error("expected error for okx error code")
+	}
+}
+
+func TestOkxBadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	okx := &OkxExchange{baseURL: srv.URL, name: "OKX", maxRetries: 2}
+
+	_, err := okx.GetAds("USDT", "EUR", "BUY")
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("expected error after attempts, got %v", err)
+	}
+}
+
+func TestOkxGetBestAdv(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	ad, err := okx.GetBestAdv("USDT", "EUR", "BUY", []string{"Wise"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if ad.GetPrice() != 0.93 {
+		t.Errorf("expected best Wise ad price 0.93, got %f", ad.GetPrice())
+	}
+
+	if _, err := okx.GetBestAdv("USDT", "EUR", "BUY", []string{"PayPal"}); err == nil {
+		t.Error("expected error when no ads accept payment method")
+	}
+}
+
+func TestOkxGetAdsByName(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	ads, err := okx.GetAdsByName("USDT", "EUR", "BUY", "anton_p2p", []string{})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(ads) != 2 {
+		t.Errorf("expected 2 ads, got %d", len(ads))
+	}
+
+	ads, err = okx.GetAdsByName("USDT", "EUR", "BUY", "anton_p2p", []string{"Revolut"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(ads) != 1 || ads[0].GetPaymentMethods()[0] != "Revolut" {
+		t.Errorf("expected only Revolut ad, got %v", ads)
+	}
+
+	if _, err := okx.GetAdsByName("USDT", "EUR", "BUY", "nobody", []string{}); err == nil {
+		t.Error("expected error for unknown username")
+	}
+}
+
+func TestOkxFetchPaymentMethods(t *testing.T) {
+	okx := newOkxTestExchange(t)
+
+	currencies, err := okx.FetchCurrencies()
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(currencies) != 2 || currencies[0] != "EUR" || currencies[1] != "CZK" {
+		t.Fatalf("unexpected currencies %v", currencies)
+	}
+
+	methods, err := okx.FetchPaymentMethods(currencies)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	name, err := GetPMethodName(methods["EUR"], "SEPA")
+	if err != nil || name != "SEPA Instant" {
+		t.Errorf("unexpected SEPA name %q: %v", name, err)
+	}
+	if len(methods["CZK"]) != 1 {
+		t.Errorf("expected 1 CZK payment method, got %d", len(methods["CZK"]))
+	}
+}
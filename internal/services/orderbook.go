@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"p2pbot/internal/db/models"
+	"slices"
+	"strings"
+)
+
+// BookSize is number of advertisements streamed in live order book
+const BookSize = 20
+
+// BookEventsKey returns routing key of live order book on EventsExchange
+func BookEventsKey(key models.BookKey) string {
+	return strings.ToLower(fmt.Sprintf("book.%s.%s.%s.%s", key.Exchange, key.Asset, key.Currency, key.Side))
+}
+
+// BookAd is advertisement of live order book
+type BookAd struct {
+	// ID identifies advertisement between ticks, it is advertisement id
+	// or advertiser and methods if exchange doesn't return advertisement id
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Advertiser AdvertiserInfo `json:"advertiser"`
+	Price      float64        `json:"price"`
+	Quantity   float64        `json:"quantity"`
+	MinAmount  float64        `json:"min_amount"`
+	MaxAmount  float64        `json:"max_amount"`
+	Payment    []string       `json:"payment_methods"`
+	// PaymentTimeLimit is minutes buyer has to pay
+	PaymentTimeLimit int    `json:"payment_time_limit"`
+	Remarks          string `json:"remarks"`
+}
+
+// Book is top of advertisement book published by observer
+type Book struct {
+	models.BookKey
+	Ads []BookAd `json:"ads"`
+}
+
+// NewBook creates live order book from BookSize best advertisements, ads must be sorted best first
+func NewBook(key models.BookKey, ads []P2PItemI) Book {
+	book := Book{BookKey: key, Ads: make([]BookAd, 0, BookSize)}
+	for _, ad := range ads {
+		if len(book.Ads) == BookSize {
+			break
+		}
+		adv := ad.ToAdvertisement()
+		id := adv.ID
+		if id == "" {
+			methods := slices.Clone(adv.PaymentMethods)
+			slices.Sort(methods)
+			id = adv.Advertiser.Nickname + "|" + strings.Join(methods, ",")
+		}
+		book.Ads = append(book.Ads, BookAd{
+			ID:               id,
+			Name:             adv.Advertiser.Nickname,
+			Advertiser:       adv.Advertiser,
+			Price:            adv.Price,
+			Quantity:         adv.Quantity,
+			MinAmount:        adv.MinAmount,
+			MaxAmount:        adv.MaxAmount,
+			Payment:          adv.PaymentMethods,
+			PaymentTimeLimit: adv.PaymentTimeLimit,
+			Remarks:          adv.Remarks,
+		})
+	}
+	return book
+}
+
+// BookDiff is difference between two ticks of order book
+type BookDiff struct {
+	Added   []BookAd `json:"added"`
+	Removed []string `json:"removed"`
+	// Changed advertisements with new price
+	Changed []BookAd `json:"changed"`
+}
+
+// Empty reports whether books are the same
+func (d BookDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffBook returns new, removed and repriced advertisements of next compared to prev
+func DiffBook(prev, next []BookAd) BookDiff {
+	diff := BookDiff{Added: []BookAd{}, Removed: []string{}, Changed: []BookAd{}}
+	prevByID := make(map[string]BookAd, len(prev))
+	for _, ad := range prev {
+		prevByID[ad.ID] = ad
+	}
+	nextIDs := make(map[string]bool, len(next))
+	for _, ad := range next {
+		nextIDs[ad.ID] = true
+		old, ok := prevByID[ad.ID]
+		if !ok {
+			diff.Added = append(diff.Added, ad)
+		} else if old.Price != ad.Price {
+			diff.Changed = append(diff.Changed, ad)
+		}
+	}
+	for _, ad := range prev {
+		if !nextIDs[ad.ID] {
+			diff.Removed = append(diff.Removed, ad.ID)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func TestNewBook(t *testing.T) {
+	ads := make([]P2PItemI, 0, BookSize+5)
+	for i := 0; i < BookSize+5; i++ {
+		ads = append(ads, OkxItem{NickName: "trader", Price: "1.00", PaymentMethods: []string{"wise", "bank"}})
+	}
+	book := NewBook(models.BookKey{Exchange: "okx"}, ads)
+	if len(book.Ads) != BookSize {
+		t.Fatalf("expected %d ads, got %d", BookSize, len(book.Ads))
+	}
+	if book.Ads[0].ID != "trader|bank,wise" {
+		t.Errorf("unexpected id %s", book.Ads[0].ID)
+	}
+	if book.Ads[0].Payment[0] != "wise" {
+		t.Error("payment methods of advertisement must keep exchange order")
+	}
+
+	book = NewBook(models.BookKey{Exchange: "okx"}, []P2PItemI{OkxItem{ID: "42", NickName: "trader", CompletedOrderQuantity: 7}})
+	if book.Ads[0].ID != "42" || book.Ads[0].Advertiser.OrderCount != 7 {
+		t.Errorf("unexpected advertisement %+v", book.Ads[0])
+	}
+}
+
+func TestDiffBook(t *testing.T) {
+	prev := []BookAd{{ID: "a", Price: 1.00}, {ID: "b", Price: 1.01}, {ID: "c", Price: 1.02}}
+	next := []BookAd{{ID: "a", Price: 1.00}, {ID: "c", Price: 1.03}, {ID: "d", Price: 1.04}}
+
+	diff := DiffBook(prev, next)
+	if len(diff.Added) != 1 || diff.Added[0].ID != "d" {
+		t.Errorf("unexpected added %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "b" {
+		t.Errorf("unexpected removed %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].ID != "c" || diff.Changed[0].Price != 1.03 {
+		t.Errorf("unexpected changed %+v", diff.Changed)
+	}
+	if !DiffBook(next, next).Empty() {
+		t.Error("expected empty diff of the same book")
+	}
+}
+
+func TestBookEventsKey(t *testing.T) {
+	key := models.BookKey{Exchange: "binance", Asset: "USDT", Currency: "EUR", Side: "BUY"}
+	if k := BookEventsKey(key); k != "book.binance.usdt.eur.buy" {
+		t.Errorf("unexpected key %s", k)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"p2pbot/internal/db/models"
+	"slices"
+	"strings"
+)
+
+/*
+ValidateOutbidRules checks tracker outbid rules
+
+return error if any rule is negative,
+percent gap is not below 100 or completion rate is above 100
+*/
+func ValidateOutbidRules(rules models.OutbidRules) error {
+	if rules.MinGap < 0 || rules.MinQuantity < 0 || rules.MinCompletionRate < 0 || rules.MinOrders < 0 {
+		return fmt.Errorf("Outbid rules must not be negative")
+	}
+	if rules.GapIsPercent && rules.MinGap >= 100 {
+		return fmt.Errorf("Percent gap must be below 100")
+	}
+	if rules.MinCompletionRate > 100 {
+		return fmt.Errorf("Completion rate must not be above 100")
+	}
+	return nil
+}
+
+// CompetitorCounts reports if competitor advertisement is big enough
+// and its advertiser is experienced enough to count as outbid
+func CompetitorCounts(rules models.OutbidRules, ad P2PItemI) bool {
+	adv := ad.ToAdvertisement()
+	return adv.Quantity >= rules.MinQuantity &&
+		adv.Advertiser.CompletionRate >= rules.MinCompletionRate &&
+		adv.Advertiser.OrderCount >= rules.MinOrders &&
+		(!rules.OnlyMerchants || adv.Advertiser.IsMerchant)
+}
+
+// ExceedsGap reports if competitor price differs from tracker price
+// at least by rules min gap, any difference counts if min gap is 0
+func ExceedsGap(rules models.OutbidRules, trackerPrice, price float64) bool {
+	gap := math.Abs(price - trackerPrice)
+	if gap == 0 {
+		return false
+	}
+	if rules.GapIsPercent {
+		if trackerPrice <= 0 {
+			return true
+		}
+		gap = gap / trackerPrice * 100
+	}
+	return gap >= rules.MinGap
+}
+
+// MaxNicknames is the largest number of nicknames in one list
+const MaxNicknames = 50
+
+// NormalizeNicknames trims and deduplicates nicknames,
+// return error if list is too long or nickname is empty or longer than 64 symbols
+func NormalizeNicknames(nicknames []string) ([]string, error) {
+	if len(nicknames) > MaxNicknames {
+		return nil, fmt.Errorf("No more than %d nicknames allowed", MaxNicknames)
+	}
+	out := make([]string, 0, len(nicknames))
+	for _, nickname := range nicknames {
+		nickname = strings.TrimSpace(nickname)
+		if nickname == "" || len(nickname) > 64 {
+			return nil, fmt.Errorf("Nickname must be 1-64 symbols long")
+		}
+		if !slices.ContainsFunc(out, func(n string) bool { return strings.EqualFold(n, nickname) }) {
+			out = append(out, nickname)
+		}
+	}
+	return out, nil
+}
+
+// NicknameCounts reports if competitor nickname counts as outbidder,
+// friendly nicknames never count, non empty only list is the only one which counts
+func NicknameCounts(nickname string, friendly, only []string) bool {
+	equal := func(n string) bool { return strings.EqualFold(n, nickname) }
+	if slices.ContainsFunc(friendly, equal) {
+		return false
+	}
+	return len(only) == 0 || slices.ContainsFunc(only, equal)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func TestCompetitorCounts(t *testing.T) {
+	ad := OkxItem{
+		AvailableAmount:        "150",
+		CompletedOrderQuantity: 40,
+		CompletedRate:          "0.95",
+	}
+	tests := []struct {
+		name  string
+		rules models.OutbidRules
+		want  bool
+	}{
+		{"no rules", models.OutbidRules{}, true},
+		{"big enough", models.OutbidRules{MinQuantity: 100, MinCompletionRate: 90, MinOrders: 40}, true},
+		{"too small", models.OutbidRules{MinQuantity: 200}, false},
+		{"low completion rate", models.OutbidRules{MinCompletionRate: 97.5}, false},
+		{"new advertiser", models.OutbidRules{MinOrders: 50}, false},
+		{"not a merchant", models.OutbidRules{OnlyMerchants: true}, false},
+	}
+	for _, tt := range tests {
+		if got := CompetitorCounts(tt.rules, ad); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+	ad.CreatorType = "certified"
+	if !CompetitorCounts(models.OutbidRules{OnlyMerchants: true}, ad) {
+		t.Error("merchant advertisement must count")
+	}
+}
+
+func TestCompletionRateInPercents(t *testing.T) {
+	items := []P2PItemI{
+		DataItem{Advertiser: Advertiser{MonthFinishRate: 0.95}},
+		Item{RecentExecuteRate: 95},
+		OkxItem{CompletedRate: "0.95"},
+	}
+	for _, item := range items {
+		if rate := item.GetCompletionRate(); rate < 94.99 || rate > 95.01 {
+			t.Errorf("%T: expected 95%%, got %f", item, rate)
+		}
+	}
+}
+
+func TestExceedsGap(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules models.OutbidRules
+		price float64
+		want  bool
+	}{
+		{"same price", models.OutbidRules{}, 1.00, false},
+		{"any gap", models.OutbidRules{}, 1.001, true},
+		{"absolute gap too small", models.OutbidRules{MinGap: 0.01}, 1.005, false},
+		{"absolute gap", models.OutbidRules{MinGap: 0.01}, 0.98, true},
+		{"percent gap too small", models.OutbidRules{MinGap: 1, GapIsPercent: true}, 1.005, false},
+		{"percent gap", models.OutbidRules{MinGap: 1, GapIsPercent: true}, 1.02, true},
+	}
+	for _, tt := range tests {
+		if got := ExceedsGap(tt.rules, 1.00, tt.price); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestValidateOutbidRules(t *testing.T) {
+	valid := []models.OutbidRules{
+		{},
+		{MinGap: 0.5, GapIsPercent: true, MinQuantity: 100, MinCompletionRate: 95, MinOrders: 10},
+	}
+	for _, r := range valid {
+		if err := ValidateOutbidRules(r); err != nil {
+			t.Errorf("expected valid rules %+v, got %v", r, err)
+		}
+	}
+	invalid := []models.OutbidRules{
+		{MinGap: -1},
+		{MinGap: 100, GapIsPercent: true},
+		{MinCompletionRate: 101},
+		{MinOrders: -5},
+	}
+	for _, r := range invalid {
+		if err := ValidateOutbidRules(r); err == nil {
+			t.Errorf("expected error for %+v", r)
+		}
+	}
+}
+
+func TestNicknameCounts(t *testing.T) {
+	friendly := []string{"Team_Second"}
+	if NicknameCounts("team_second", friendly, nil) {
+		t.Error("friendly nickname must not count")
+	}
+	if !NicknameCounts("stranger", friendly, nil) {
+		t.Error("stranger must count without only list")
+	}
+	only := []string{"rival"}
+	if NicknameCounts("stranger", friendly, only) {
+		t.Error("stranger must not count with only list")
+	}
+	if !NicknameCounts("Rival", friendly, only) {
+		t.Error("rival must count")
+	}
+}
+
+func TestNormalizeNicknames(t *testing.T) {
+	got, err := NormalizeNicknames([]string{" alice ", "Alice", "bob"})
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "alice" || got[1] != "bob" {
+		t.Errorf("unexpected nicknames %v", got)
+	}
+	if _, err := NormalizeNicknames([]string{"  "}); err == nil {
+		t.Error("expected error for empty nickname")
+	}
+	if _, err := NormalizeNicknames(make([]string, MaxNicknames+1)); err == nil {
+		t.Error("expected error for too many nicknames")
+	}
+}
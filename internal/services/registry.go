@@ -0,0 +1,122 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"p2pbot/internal/config"
+	"sort"
+	"sync"
+)
+
+// ExchangeConstructor creates exchange adapter from config
+type ExchangeConstructor func(cfg *config.Config) ExchangeI
+
+// ItemDecoder decodes exchange specific advertisement, used for notifications
+type ItemDecoder func(data []byte) (P2PItemI, error)
+
+type registryEntry struct {
+	constructor ExchangeConstructor
+	decoder     ItemDecoder
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]registryEntry)
+)
+
+/*
+RegisterExchange adds exchange adapter to the registry.
+Adapters call it once from init(), name is the lowercase exchange name
+used in trackers, API and notifications (binance, bybit, okx)
+*/
+func RegisterExchange(name string, constructor ExchangeConstructor, decoder ItemDecoder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("exchange %s registered twice", name))
+	}
+	registry[name] = registryEntry{constructor: constructor, decoder: decoder}
+}
+
+// RegisteredExchanges returns sorted names of all registered exchanges
+func RegisteredExchanges() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make([]string, 0, len(registry))
+	for name := range registry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// DecodeItem decodes advertisement of given exchange
+func DecodeItem(exchange string, data []byte) (P2PItemI, error) {
+	registryMu.RLock()
+	entry, ok := registry[exchange]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no unmarshal logic for %s", exchange)
+	}
+	return entry.decoder(data)
+}
+
+// jsonItemDecoder returns ItemDecoder which unmarshals data into T
+func jsonItemDecoder[T P2PItemI]() ItemDecoder {
+	return func(data []byte) (P2PItemI, error) {
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, err
+		}
+		return item, nil
+	}
+}
+
+// Exchanges maps exchange name to its adapter
+type Exchanges map[string]ExchangeI
+
+/*
+NewExchanges creates adapters for exchanges enabled in config.
+If exchange.enabled is empty, all registered exchanges are enabled
+return error if config enables exchange which is not registered
+*/
+func NewExchanges(cfg *config.Config) (Exchanges, error) {
+	names := cfg.Exchange.Enabled
+	if len(names) == 0 {
+		names = RegisteredExchanges()
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	out := make(Exchanges)
+	for _, name := range names {
+		entry, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("exchange %s not supported", name)
+		}
+		out[name] = entry.constructor(cfg)
+	}
+	return out, nil
+}
+
+// Names returns sorted names of exchanges
+func (e Exchanges) Names() []string {
+	out := make([]string, 0, len(e))
+	for name := range e {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// List returns exchanges sorted by name
+func (e Exchanges) List() []ExchangeI {
+	out := make([]ExchangeI, 0, len(e))
+	for _, name := range e.Names() {
+		out = append(out, e[name])
+	}
+	return out
+}
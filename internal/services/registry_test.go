@@ -0,0 +1,48 @@
+package services
+
+import (
+	"p2pbot/internal/config"
+	"testing"
+)
+
+func TestNewExchangesEnabled(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Exchange.Enabled = []string{"okx", "binance"}
+
+	exs, err := NewExchanges(cfg)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	names := exs.Names()
+	if len(names) != 2 || names[0] != "binance" || names[1] != "okx" {
+		t.Errorf("unexpected exchanges %v", names)
+	}
+
+	cfg.Exchange.Enabled = []string{"kraken"}
+	if _, err := NewExchanges(cfg); err == nil {
+		t.Error("expected error for unregistered exchange")
+	}
+
+	cfg.Exchange.Enabled = nil
+	exs, err = NewExchanges(cfg)
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if len(exs) != len(RegisteredExchanges()) {
+		t.Errorf("expected all registered exchanges, got %v", exs.Names())
+	}
+}
+
+func TestDecodeItem(t *testing.T) {
+	item, err := DecodeItem("bybit", []byte(`{"nickName":"trader","price":"24.5"}`))
+	if err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if item.GetName() != "trader" || item.GetPrice() != 24.5 {
+		t.Errorf("unexpected item %v", item)
+	}
+
+	if _, err := DecodeItem("kraken", []byte(`{}`)); err == nil {
+		t.Error("expected error for unregistered exchange")
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"slices"
+	"strings"
+	"time"
+	// images have no zoneinfo, timezones of user settings are embedded
+	_ "time/tzdata"
+)
+
+// MaxPerHourLimit is the highest hourly notification limit user can set
+const MaxPerHourLimit = 1000
+
+// clockLayout is format of quiet hours
+const clockLayout = "15:04"
+
+// PreferredChannels are channel kinds user can choose in settings
+var PreferredChannels = []string{
+	models.ChannelTelegram,
+	models.ChannelDiscord,
+	models.ChannelSlack,
+	models.ChannelEmail,
+	models.ChannelWebhook,
+}
+
+type SettingsService struct {
+	repo *repository.SettingsRepository
+}
+
+func NewSettingsService(repo *repository.SettingsRepository) *SettingsService {
+	return &SettingsService{repo: repo}
+}
+
+// DefaultSettings are settings of user who has not saved them:
+// no quiet hours, no hourly limit and all channels
+func DefaultSettings(userID int) *models.UserSettings {
+	return &models.UserSettings{
+		UserID:   userID,
+		Timezone: "UTC",
+		Channels: make([]string, 0),
+	}
+}
+
+/*
+ValidateSettings checks and normalizes user settings
+
+return error if timezone is unknown, only one of quiet hours bounds is set,
+bounds are not HH:MM or equal, hourly limit is not between 0 and MaxPerHourLimit
+or channel is not one of PreferredChannels
+*/
+func ValidateSettings(s *models.UserSettings) error {
+	if s == nil {
+		return fmt.Errorf("Settings are nil")
+	}
+	s.Timezone = strings.TrimSpace(s.Timezone)
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("Unknown timezone %s", s.Timezone)
+	}
+
+	s.QuietStart = strings.TrimSpace(s.QuietStart)
+	s.QuietEnd = strings.TrimSpace(s.QuietEnd)
+	if (s.QuietStart == "") != (s.QuietEnd == "") {
+		return fmt.Errorf("Both quiet hours start and end must be set")
+	}
+	if s.QuietStart != "" {
+		start, err := time.Parse(clockLayout, s.QuietStart)
+		if err != nil {
+			return fmt.Errorf("Quiet hours start must be HH:MM")
+		}
+		end, err := time.Parse(clockLayout, s.QuietEnd)
+		if err != nil {
+			return fmt.Errorf("Quiet hours end must be HH:MM")
+		}
+		if start.Equal(end) {
+			return fmt.Errorf("Quiet hours start and end must differ")
+		}
+		s.QuietStart = start.Format(clockLayout)
+		s.QuietEnd = end.Format(clockLayout)
+	}
+
+	if s.MaxPerHour < 0 || s.MaxPerHour > MaxPerHourLimit {
+		return fmt.Errorf("Max notifications per hour must be between 0 and %d", MaxPerHourLimit)
+	}
+
+	channels := make([]string, 0, len(s.Channels))
+	for _, ch := range s.Channels {
+		ch = strings.ToLower(strings.TrimSpace(ch))
+		if !slices.Contains(PreferredChannels, ch) {
+			return fmt.Errorf("Channel must be one of %v", PreferredChannels)
+		}
+		if !slices.Contains(channels, ch) {
+			channels = append(channels, ch)
+		}
+	}
+	s.Channels = channels
+	return nil
+}
+
+// Location returns timezone of user settings, UTC if timezone is unknown
+func Location(s *models.UserSettings) *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+/*
+InQuietHours reports whether t is within quiet hours of settings in user's timezone,
+quiet hours can span midnight(22:00-07:00)
+*/
+func InQuietHours(s *models.UserSettings, t time.Time) bool {
+	if s == nil || s.QuietStart == "" || s.QuietEnd == "" {
+		return false
+	}
+	start, err := time.Parse(clockLayout, s.QuietStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockLayout, s.QuietEnd)
+	if err != nil {
+		return false
+	}
+	local := t.In(Location(s))
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
+
+// AllowsChannel reports whether notifications may be sent to channel kind,
+// all channels are allowed when no channels are preferred
+func AllowsChannel(channels []string, kind string) bool {
+	return len(channels) == 0 || slices.Contains(channels, kind)
+}
+
+// Get returns user settings or default settings if user has not saved them
+func (s *SettingsService) Get(userID int) (*models.UserSettings, error) {
+	settings, err := s.repo.GetByUserID(userID)
+	if err == sql.ErrNoRows {
+		return DefaultSettings(userID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *SettingsService) Save(settings *models.UserSettings) error {
+	return s.repo.Save(settings)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+	"time"
+)
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings models.UserSettings
+		valid    bool
+	}{
+		{"defaults", models.UserSettings{}, true},
+		{"quiet hours", models.UserSettings{Timezone: "Europe/Berlin", QuietStart: "22:00", QuietEnd: "7:30"}, true},
+		{"unknown timezone", models.UserSettings{Timezone: "Mars/Olympus"}, false},
+		{"only start", models.UserSettings{QuietStart: "22:00"}, false},
+		{"bad clock", models.UserSettings{QuietStart: "25:00", QuietEnd: "07:00"}, false},
+		{"empty quiet hours", models.UserSettings{QuietStart: "07:00", QuietEnd: "07:00"}, false},
+		{"hourly limit", models.UserSettings{MaxPerHour: 5}, true},
+		{"negative limit", models.UserSettings{MaxPerHour: -1}, false},
+		{"channels", models.UserSettings{Channels: []string{"Telegram", "email", "telegram"}}, true},
+		{"unknown channel", models.UserSettings{Channels: []string{"sms"}}, false},
+	}
+	for _, tt := range tests {
+		s := tt.settings
+		err := ValidateSettings(&s)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid settings, got %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+
+	s := &models.UserSettings{QuietStart: "7:05", QuietEnd: "22:00", Channels: []string{" Email ", "email"}}
+	if err := ValidateSettings(s); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if s.Timezone != "UTC" || s.QuietStart != "07:05" || len(s.Channels) != 1 || s.Channels[0] != "email" {
+		t.Errorf("settings are not normalized: %+v", s)
+	}
+}
+
+func TestInQuietHours(t *testing.T) {
+	overnight := &models.UserSettings{Timezone: "Europe/Berlin", QuietStart: "22:00", QuietEnd: "07:00"}
+	day := &models.UserSettings{Timezone: "UTC", QuietStart: "12:00", QuietEnd: "13:00"}
+	tests := []struct {
+		name     string
+		settings *models.UserSettings
+		at       string
+		want     bool
+	}{
+		{"no quiet hours", DefaultSettings(1), "2025-01-10T23:00:00Z", false},
+		// 23:30 in Berlin
+		{"overnight before midnight", overnight, "2025-01-10T22:30:00Z", true},
+		// 06:59 in Berlin
+		{"overnight after midnight", overnight, "2025-01-10T05:59:00Z", true},
+		// 07:00 in Berlin
+		{"overnight ended", overnight, "2025-01-10T06:00:00Z", false},
+		// 21:59 in Berlin
+		{"overnight not started", overnight, "2025-01-10T20:59:00Z", false},
+		{"day", day, "2025-01-10T12:30:00Z", true},
+		{"after day", day, "2025-01-10T13:00:00Z", false},
+	}
+	for _, tt := range tests {
+		at, err := time.Parse(time.RFC3339, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := InQuietHours(tt.settings, at); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAllowsChannel(t *testing.T) {
+	if !AllowsChannel(nil, models.ChannelSlack) {
+		t.Error("all channels must be allowed without preferences")
+	}
+	if AllowsChannel([]string{models.ChannelTelegram}, models.ChannelSlack) {
+		t.Error("slack must not be allowed")
+	}
+	if !AllowsChannel([]string{models.ChannelTelegram}, models.ChannelTelegram) {
+		t.Error("telegram must be allowed")
+	}
+}
+
+func TestSummaryLine(t *testing.T) {
+	n := Notification{Exchange: "binance", Asset: "USDT", Currency: "EUR", Side: "BUY", Data: OkxItem{NickName: "rival", Price: "1.01"}}
+	at := time.Date(2025, 1, 10, 23, 15, 0, 0, time.UTC)
+	if line := SummaryLine(n, at); line != "23:15 outbid binance USDT/EUR BUY: rival 1.01EUR" {
+		t.Errorf("unexpected line %q", line)
+	}
+}
@@ -1,23 +1,51 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
 	"p2pbot/internal/db/models"
 	"p2pbot/internal/db/repository"
+	"p2pbot/internal/rediscl"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// SupportedAssets are crypto assets which can be tracked
+var SupportedAssets = []string{"USDT", "BTC", "ETH", "USDC", "FDUSD"}
+
+// DefaultAsset is asset of trackers, books and notifications which don't set it
+const DefaultAsset = "USDT"
+
+// NormalizeAsset returns upper case asset ticker, DefaultAsset if asset is empty
+func NormalizeAsset(asset string) string {
+	asset = strings.ToUpper(asset)
+	if asset == "" {
+		return DefaultAsset
+	}
+	return asset
+}
+
+// MaxTrackerInterval is the longest polling interval of tracker in seconds
+const MaxTrackerInterval = 24 * 60 * 60
+
+// StagingTTL is how long unfinished tracker is kept in staging area
+const StagingTTL = 24 * time.Hour
+
 type TrackerService struct {
 	repo      *repository.TrackerRepository
 	Exchanges map[string]bool
-	trStaging map[int]*models.Tracker
 }
 
-func NewTrackerService(repo *repository.TrackerRepository) *TrackerService {
+func NewTrackerService(repo *repository.TrackerRepository, exs Exchanges) *TrackerService {
+	// Only exchanges enabled in config are allowed for trackers
 	exchanges := make(map[string]bool)
-	exchanges["binance"] = true
-	exchanges["bybit"] = true
-	return &TrackerService{repo: repo, Exchanges: exchanges, trStaging: make(map[int]*models.Tracker)}
+	for _, name := range exs.Names() {
+		exchanges[name] = true
+	}
+	return &TrackerService{repo: repo, Exchanges: exchanges}
 }
 
 /*
@@ -27,7 +55,13 @@ tracker - tracker to create
 staging - if true, tracker will be removed from staging area after creation
 (use true if added to staging before)
 return error if tracker is nil, side is not BUY/SELL,
-currency length is not 3 or exchange is not supported
+currency length is not 3, asset or exchange is not supported.
+Empty asset defaults to USDT, empty kind defaults to outbid.
+Price alerts need positive threshold and direction below/above,
+spread alerts need positive threshold(%) and another supported exchange to compare with.
+Interval must be between 0 and MaxTrackerInterval seconds,
+outbid rules are checked with ValidateOutbidRules,
+nickname lists are normalized and must not share nicknames
 */
 
 func (s *TrackerService) ValidateTracker(tracker *models.Tracker, staging bool) error {
@@ -45,16 +79,95 @@ func (s *TrackerService) ValidateTracker(tracker *models.Tracker, staging bool)
 		return fmt.Errorf("Currency ticker must be 3 symbols long, EUR for example")
 	}
 
+	tracker.Asset = NormalizeAsset(tracker.Asset)
+	if !slices.Contains(SupportedAssets, tracker.Asset) {
+		return fmt.Errorf("asset %s not supported", tracker.Asset)
+	}
+
 	tracker.Exchange = strings.ToLower(tracker.Exchange)
 	if _, ok := s.Exchanges[tracker.Exchange]; !ok {
 		return fmt.Errorf("exchange %s not supported", tracker.Exchange)
 	}
 
+	if err := s.validateKind(tracker); err != nil {
+		return err
+	}
+
+	if err := ValidateInterval(tracker.Interval); err != nil {
+		return err
+	}
+
+	if err := ValidateOutbidRules(tracker.OutbidRules); err != nil {
+		return err
+	}
+
+	if err := validateNicknames(tracker); err != nil {
+		return err
+	}
+
 	// Remove tracker from staging area
 	if staging {
-		s.DeleteTrackerStaging(tracker.UserID)
+		if err := s.DeleteTrackerStaging(tracker.UserID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ValidateInterval checks tracker polling interval(seconds),
+// 0 means the fastest interval allowed by subscription
+func ValidateInterval(interval int) error {
+	if interval < 0 || interval > MaxTrackerInterval {
+		return fmt.Errorf("Interval must be between 0 and %d seconds", MaxTrackerInterval)
+	}
+	return nil
+}
+
+func validateNicknames(tracker *models.Tracker) error {
+	var err error
+	if tracker.IgnoreNicknames, err = NormalizeNicknames(tracker.IgnoreNicknames); err != nil {
+		return err
+	}
+	if tracker.OnlyNicknames, err = NormalizeNicknames(tracker.OnlyNicknames); err != nil {
+		return err
 	}
+	for _, nickname := range tracker.OnlyNicknames {
+		if !NicknameCounts(nickname, tracker.IgnoreNicknames, nil) {
+			return fmt.Errorf("Nickname %s is both ignored and only", nickname)
+		}
+	}
+	return nil
+}
 
+func (s *TrackerService) validateKind(tracker *models.Tracker) error {
+	tracker.Kind = strings.ToLower(tracker.Kind)
+	switch tracker.Kind {
+	case "", models.TrackerKindOutbid:
+		tracker.Kind = models.TrackerKindOutbid
+	case models.TrackerKindPrice:
+		if tracker.Threshold <= 0 {
+			return fmt.Errorf("Threshold must be positive")
+		}
+		tracker.Direction = strings.ToLower(tracker.Direction)
+		if tracker.Direction != "below" && tracker.Direction != "above" {
+			return fmt.Errorf("Direction must be below/above")
+		}
+	case models.TrackerKindSpread:
+		if tracker.Threshold <= 0 {
+			return fmt.Errorf("Threshold must be positive")
+		}
+		tracker.Direction = "above"
+		tracker.CompareExchange = strings.ToLower(tracker.CompareExchange)
+		if _, ok := s.Exchanges[tracker.CompareExchange]; !ok {
+			return fmt.Errorf("exchange %s not supported", tracker.CompareExchange)
+		}
+		if tracker.CompareExchange == tracker.Exchange {
+			return fmt.Errorf("Compare exchange must differ from tracker exchange")
+		}
+	default:
+		return fmt.Errorf("Kind must be outbid/price/spread")
+	}
 	return nil
 }
 
@@ -72,6 +185,11 @@ func (s *TrackerService) SetWaitingFlag(id int64, flag bool) error {
 	return s.repo.UpdateWaitingUpdate(id, flag)
 }
 
+// SetNotify mutes or unmutes tracker until it is changed again
+func (s *TrackerService) SetNotify(id int64, notify bool) error {
+	return s.repo.UpdateNotify(id, notify)
+}
+
 func (s *TrackerService) GetAllTrackers() ([]*models.UserTracker, error) {
 	return s.repo.GetAllTrackers()
 }
@@ -92,25 +210,77 @@ func (s *TrackerService) DeleteTracker(id int) error {
 	return err
 }
 
-func (s *TrackerService) GetTrackerStaging(id int) *models.Tracker {
-	tr, ok := s.trStaging[id]
-	if !ok {
-		tr := &models.Tracker{}
-		s.trStaging[id] = tr
-		return tr
+func stagingKey(userID int) string {
+	return fmt.Sprintf("tracker_staging:%d", userID)
+}
+
+// GetTrackerStaging returns unfinished tracker of user from redis,
+// empty tracker if user has none
+func (s *TrackerService) GetTrackerStaging(id int) (*models.Tracker, error) {
+	tr := &models.Tracker{}
+	data, err := rediscl.RDB.Client.Get(rediscl.RDB.Ctx, stagingKey(id)).Bytes()
+	if err == redis.Nil {
+		return tr, nil
+	}
+	if err != nil {
+		return nil, err
 	}
-	return tr
+	if err := json.Unmarshal(data, tr); err != nil {
+		return nil, err
+	}
+	return tr, nil
 }
 
-func (s *TrackerService) DeleteTrackerStaging(id int) {
-	delete(s.trStaging, id)
+// SaveTrackerStaging stores unfinished tracker of user for StagingTTL
+func (s *TrackerService) SaveTrackerStaging(id int, tracker *models.Tracker) error {
+	data, err := json.Marshal(tracker)
+	if err != nil {
+		return err
+	}
+	return rediscl.RDB.Client.Set(rediscl.RDB.Ctx, stagingKey(id), data, StagingTTL).Err()
+}
+
+func (s *TrackerService) DeleteTrackerStaging(id int) error {
+	return rediscl.RDB.Client.Del(rediscl.RDB.Ctx, stagingKey(id)).Err()
+}
+
+// ResetOutbid marks tracker as not outbidded, so user is notified on next outbid
+func (s *TrackerService) ResetOutbid(id int64) error {
+	return s.repo.ResetOutbid(id)
+}
+
+func muteKey(trackerID int64) string {
+	return fmt.Sprintf("tracker_mute:%d", trackerID)
+}
+
+// MuteTracker stops notifications of tracker for duration
+func (s *TrackerService) MuteTracker(id int64, duration time.Duration) error {
+	return rediscl.RDB.Client.Set(rediscl.RDB.Ctx, muteKey(id), 1, duration).Err()
+}
+
+// IsMuted returns true if tracker was muted with MuteTracker and mute didn't expire
+func (s *TrackerService) IsMuted(id int64) (bool, error) {
+	n, err := rediscl.RDB.Client.Exists(rediscl.RDB.Ctx, muteKey(id)).Result()
+	return n > 0, err
 }
 
 func (s *TrackerService) UpdateMethodOutbiddded(tracker_id int64, pm string, outbid bool) error {
 	return s.repo.UpdatePaymentMethodOutbided(tracker_id, pm, outbid)
 }
 
-// GetIdsByCurrency returns map of "currency+side"(CZKSELL) to tracker ids for given exchange
-func (s *TrackerService) GetIdsByCurrency(exchange string) (map[string][]int, error) {
-	return s.repo.GetIdsByCurrency(exchange)
+// GetSchedules returns books and polling settings of all trackers
+func (s *TrackerService) GetSchedules() ([]models.TrackerSchedule, error) {
+	return s.repo.GetSchedules()
+}
+
+// GetTeamNicknames returns nicknames of user's team accounts
+func (s *TrackerService) GetTeamNicknames(userID int) ([]string, error) {
+	return s.repo.GetTeamNicknames(userID)
+}
+
+// SetTeamNicknames replaces nicknames of user's team accounts,
+// they never count as outbidders on user's trackers.
+// Nicknames should be normalized with NormalizeNicknames
+func (s *TrackerService) SetTeamNicknames(userID int, nicknames []string) error {
+	return s.repo.SetTeamNicknames(userID, nicknames)
 }
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func newTestTrackerService() *TrackerService {
+	return NewTrackerService(nil, Exchanges{
+		"binance": &BinanceExchange{},
+		"bybit":   &BybitExchange{},
+	})
+}
+
+func TestValidateTrackerKinds(t *testing.T) {
+	s := newTestTrackerService()
+
+	tests := []struct {
+		name    string
+		tracker models.Tracker
+		valid   bool
+	}{
+		{"default outbid", models.Tracker{}, true},
+		{"price below", models.Tracker{Kind: "price", Threshold: 0.92, Direction: "BELOW"}, true},
+		{"price no threshold", models.Tracker{Kind: "price", Direction: "below"}, false},
+		{"price bad direction", models.Tracker{Kind: "price", Threshold: 1, Direction: "under"}, false},
+		{"spread", models.Tracker{Kind: "spread", Threshold: 1.5, CompareExchange: "Bybit"}, true},
+		{"spread same exchange", models.Tracker{Kind: "spread", Threshold: 1.5, CompareExchange: "binance"}, false},
+		{"spread unknown exchange", models.Tracker{Kind: "spread", Threshold: 1.5, CompareExchange: "okx"}, false},
+		{"unknown kind", models.Tracker{Kind: "volume"}, false},
+		{"custom interval", models.Tracker{Interval: 300}, true},
+		{"negative interval", models.Tracker{Interval: -1}, false},
+		{"interval above a day", models.Tracker{Interval: MaxTrackerInterval + 1}, false},
+		{"nickname lists", models.Tracker{IgnoreNicknames: []string{"team"}, OnlyNicknames: []string{"rival"}}, true},
+		{"nickname in both lists", models.Tracker{IgnoreNicknames: []string{"rival"}, OnlyNicknames: []string{"Rival"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := tt.tracker
+			tr.Exchange = "binance"
+			tr.Currency = "eur"
+			tr.Side = "buy"
+			err := s.ValidateTracker(&tr, false)
+			if tt.valid && err != nil {
+				t.Errorf("expected valid tracker, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestValidateTrackerAsset(t *testing.T) {
+	s := newTestTrackerService()
+
+	tr := &models.Tracker{Exchange: "binance", Currency: "EUR", Side: "SELL"}
+	if err := s.ValidateTracker(tr, false); err != nil {
+		t.Fatalf("Error: %v", err)
+	}
+	if tr.Asset != "USDT" || tr.Kind != models.TrackerKindOutbid {
+		t.Errorf("expected USDT outbid tracker, got %s %s", tr.Asset, tr.Kind)
+	}
+
+	tr.Asset = "doge"
+	if err := s.ValidateTracker(tr, false); err == nil {
+		t.Error("expected error for unsupported asset")
+	}
+}
+
+func TestSpread(t *testing.T) {
+	if s := Spread(0.95, 1.0); s < 5.26 || s > 5.27 {
+		t.Errorf("unexpected spread %f", s)
+	}
+	if s := Spread(1.0, 0.95); s < 5.26 || s > 5.27 {
+		t.Errorf("spread must be symmetric, got %f", s)
+	}
+	if s := Spread(0, 1); s != 0 {
+		t.Errorf("expected 0 spread for zero price, got %f", s)
+	}
+}
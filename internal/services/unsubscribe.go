@@ -0,0 +1,32 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnsubscribeToken returns token of unsubscribe link in notification emails,
+// token is user id signed with secret, so it never expires
+func UnsubscribeToken(secret string, userID int) string {
+	id := strconv.Itoa(userID)
+	return id + "." + unsubscribeSignature(secret, id)
+}
+
+// ParseUnsubscribeToken checks token signature and returns user id
+func ParseUnsubscribeToken(secret, token string) (int, error) {
+	id, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(unsubscribeSignature(secret, id))) {
+		return 0, fmt.Errorf("invalid unsubscribe token")
+	}
+	return strconv.Atoi(id)
+}
+
+func unsubscribeSignature(secret, id string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
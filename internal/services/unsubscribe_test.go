@@ -0,0 +1,25 @@
+package services
+
+import "testing"
+
+func TestUnsubscribeToken(t *testing.T) {
+	token := UnsubscribeToken("secret", 42)
+	id, err := ParseUnsubscribeToken("secret", token)
+	if err != nil || id != 42 {
+		t.Fatalf("expected user 42, got %d, %v", id, err)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"42",
+		"43" + token[2:],
+		token + "0",
+	} {
+		if _, err := ParseUnsubscribeToken("secret", invalid); err == nil {
+			t.Errorf("expected error for token %q", invalid)
+		}
+	}
+	if _, err := ParseUnsubscribeToken("other", token); err == nil {
+		t.Error("expected error for token signed with another secret")
+	}
+}
@@ -28,3 +28,8 @@ func (s *UserService) GetUserByID(id int) (*models.User, error) {
 func (s *UserService) GetUserByEmail(email string) (*models.User, error) {
 	return s.repo.GetByEmail(email)
 }
+
+// SetEmailNotifications enables or disables notification emails of user
+func (s *UserService) SetEmailNotifications(id int, enabled bool) error {
+	return s.repo.SetEmailNotifications(id, enabled)
+}
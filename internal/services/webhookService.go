@@ -0,0 +1,254 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/db/repository"
+	"strings"
+	"time"
+)
+
+// MaxWebhooks is the most webhooks user can register
+const MaxWebhooks = 5
+
+// WebhookEventTest is type of event sent by "send test event" endpoint
+const WebhookEventTest = "test"
+
+// WebhookEvent is JSON body posted to user's webhooks
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      EventData `json:"data"`
+}
+
+// EventData is notification data of webhook event
+type EventData struct {
+	TrackerID       int64    `json:"tracker_id,omitempty"`
+	Exchange        string   `json:"exchange"`
+	Asset           string   `json:"asset"`
+	Currency        string   `json:"currency"`
+	Side            string   `json:"side,omitempty"`
+	Advertiser      string   `json:"advertiser"`
+	Price           float64  `json:"price"`
+	Quantity        float64  `json:"quantity"`
+	MinAmount       float64  `json:"min_amount"`
+	MaxAmount       float64  `json:"max_amount"`
+	PaymentMethods  []string `json:"payment_methods"`
+	Threshold       float64  `json:"threshold,omitempty"`
+	Direction       string   `json:"direction,omitempty"`
+	CompareExchange string   `json:"compare_exchange,omitempty"`
+	ComparePrice    float64  `json:"compare_price,omitempty"`
+	CompareName     string   `json:"compare_name,omitempty"`
+	Spread          float64  `json:"spread,omitempty"`
+	// Summary lines of notifications held during quiet hours
+	Summary []string `json:"summary,omitempty"`
+}
+
+// NewEventID returns random id of webhook event
+func NewEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+/*
+MessageEventID returns id of webhook event of notification message,
+the same for every delivery of message, so receivers can dedupe redelivered events.
+
+It is id of envelope, messages published before envelopes are identified by hash
+*/
+func MessageEventID(body []byte) string {
+	var e struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.ID != "" {
+		return e.ID
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:16])
+}
+
+// NewWebhookEvent converts notification to webhook event with id,
+// event type is notification kind(outbid, price, spread or arbitrage)
+func NewWebhookEvent(id string, n Notification) WebhookEvent {
+	e := WebhookEvent{
+		ID:        id,
+		Type:      n.Kind,
+		CreatedAt: time.Now().UTC(),
+		Data: EventData{
+			TrackerID:       n.TrackerID,
+			Exchange:        n.Exchange,
+			Asset:           n.Asset,
+			Currency:        n.Currency,
+			Side:            n.Side,
+			PaymentMethods:  make([]string, 0),
+			Threshold:       n.Threshold,
+			Direction:       n.Direction,
+			CompareExchange: n.CompareExchange,
+			ComparePrice:    n.ComparePrice,
+			CompareName:     n.CompareName,
+			Spread:          n.Spread,
+			Summary:         n.Summary,
+		},
+	}
+	if e.Type == "" {
+		e.Type = models.TrackerKindOutbid
+	}
+	if n.Data != nil {
+		e.Data.Advertiser = n.Data.GetName()
+		e.Data.Price = n.Data.GetPrice()
+		e.Data.Quantity, e.Data.MinAmount, e.Data.MaxAmount = n.Data.GetQuantity()
+		e.Data.PaymentMethods = n.Data.GetPaymentMethods()
+	}
+	if len(n.Methods) > 0 {
+		e.Data.PaymentMethods = n.Methods
+	}
+	return e
+}
+
+/*
+SignPayload returns hex HMAC-SHA256 of "timestamp.body" with webhook secret,
+receivers should compute the same signature and reject old timestamps
+*/
+func SignPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewWebhookSecret returns random secret for signing events
+func NewWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+type WebhookService struct {
+	repo *repository.WebhookRepository
+}
+
+func NewWebhookService(repo *repository.WebhookRepository) *WebhookService {
+	return &WebhookService{repo: repo}
+}
+
+// lookupIP resolves webhook hosts, replaced in tests
+var lookupIP = net.LookupIP
+
+/*
+PublicIP reports whether ip can be webhook address.
+
+Loopback, private, link-local and unspecified addresses are rejected,
+so webhooks can't reach services of internal network
+*/
+func PublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast())
+}
+
+/*
+ValidateWebhook checks webhook fields
+
+return error if url is not absolute https url, its host resolves
+to address which is not public or description is longer than 128 symbols.
+Dispatcher checks addresses again on connect, as host can be re-pointed later
+*/
+func ValidateWebhook(w *models.Webhook) error {
+	if w == nil {
+		return fmt.Errorf("Webhook is nil")
+	}
+	w.URL = strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("Url must be absolute https url")
+	}
+	ips, err := lookupIP(u.Hostname())
+	if err != nil || len(ips) == 0 {
+		return fmt.Errorf("Url host could not be resolved")
+	}
+	for _, ip := range ips {
+		if !PublicIP(ip) {
+			return fmt.Errorf("Url must point to public address")
+		}
+	}
+	w.Description = strings.TrimSpace(w.Description)
+	if len(w.Description) > 128 {
+		return fmt.Errorf("Description must be at most 128 symbols long")
+	}
+	return nil
+}
+
+/*
+Validate checks webhook with ValidateWebhook
+
+return error if webhook is invalid or new webhook
+is added when user already has MaxWebhooks webhooks
+*/
+func (s *WebhookService) Validate(w *models.Webhook) error {
+	if err := ValidateWebhook(w); err != nil {
+		return err
+	}
+	if w.ID == 0 {
+		webhooks, err := s.repo.GetByUserID(w.UserID)
+		if err != nil {
+			return err
+		}
+		if len(webhooks) >= MaxWebhooks {
+			return fmt.Errorf("At most %d webhooks allowed", MaxWebhooks)
+		}
+	}
+	return nil
+}
+
+// Save stores webhook, secret is generated for new webhooks
+func (s *WebhookService) Save(w *models.Webhook) error {
+	if w.ID == 0 {
+		secret, err := NewWebhookSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+	return s.repo.Save(w)
+}
+
+func (s *WebhookService) GetByID(id int) (*models.Webhook, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *WebhookService) GetByUserID(id int) ([]*models.Webhook, error) {
+	return s.repo.GetByUserID(id)
+}
+
+// GetEnabled returns webhooks events of user are posted to
+func (s *WebhookService) GetEnabled(userID int) ([]*models.Webhook, error) {
+	return s.repo.GetEnabled(userID)
+}
+
+func (s *WebhookService) Delete(id int) error {
+	count, err := s.repo.Delete(id)
+	if count == 0 && err == nil {
+		return fmt.Errorf("Webhook not found")
+	}
+	return err
+}
+
+// SaveDeadLetter stores event which was not delivered after all retries
+func (s *WebhookService) SaveDeadLetter(d *models.WebhookDeadLetter) error {
+	return s.repo.SaveDeadLetter(d)
+}
+
+// GetDeadLetters returns latest undelivered events of webhook
+func (s *WebhookService) GetDeadLetters(webhookID int64, limit int) ([]*models.WebhookDeadLetter, error) {
+	return s.repo.GetDeadLetters(webhookID, limit)
+}
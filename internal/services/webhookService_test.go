@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"p2pbot/internal/db/models"
+	"testing"
+)
+
+func TestSignPayload(t *testing.T) {
+	// echo -n '1700000000.{"id":"1"}' | openssl dgst -sha256 -hmac whsec_test
+	want := "11bf4466ea17c3df3fd743af0b435368e16b7a05eb8eced85e8c4670767bdec5"
+	if got := SignPayload("whsec_test", 1700000000, []byte(`{"id":"1"}`)); got != want {
+		t.Errorf("expected signature %s, got %s", want, got)
+	}
+	if SignPayload("whsec_test", 1700000001, []byte(`{"id":"1"}`)) == want {
+		t.Error("signature must depend on timestamp")
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "bot.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "rabbitmq":
+			return []net.IP{net.ParseIP("172.18.0.3")}, nil
+		case "localhost":
+			return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}, nil
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return []net.IP{ip}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+	t.Cleanup(func() { lookupIP = net.LookupIP })
+
+	tests := []struct {
+		name  string
+		url   string
+		valid bool
+	}{
+		{"https", " https://bot.example.com/p2phub ", true},
+		{"http", "http://bot.example.com/p2phub", false},
+		{"relative", "/p2phub", false},
+		{"no host", "https://", false},
+		{"port", "https://bot.example.com:8443/p2phub", true},
+		{"localhost", "https://localhost/p2phub", false},
+		{"loopback", "https://127.0.0.1:8080", false},
+		{"private", "https://10.0.0.5/hook", false},
+		{"metadata", "https://169.254.169.254/latest/meta-data", false},
+		{"ipv6 loopback", "https://[::1]/hook", false},
+		{"unspecified", "https://0.0.0.0/hook", false},
+		{"compose host", "https://rabbitmq/hook", false},
+		{"unresolved", "https://missing.example.com/hook", false},
+	}
+	for _, tt := range tests {
+		w := &models.Webhook{URL: tt.url}
+		err := ValidateWebhook(w)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid webhook, got %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected validation error", tt.name)
+		}
+	}
+}
+
+func TestNewWebhookEvent(t *testing.T) {
+	e := NewWebhookEvent("evt_1", Notification{
+		Kind:      NotificationKindArbitrage,
+		TrackerID: 0,
+		Exchange:  "okx",
+		Asset:     "USDT",
+		Currency:  "EUR",
+		Data:      OkxItem{NickName: "cheap", Price: "0.92", PaymentMethods: []string{"REVOLUT"}},
+		Methods:   []string{"Revolut"},
+	})
+	if e.ID != "evt_1" || e.Type != NotificationKindArbitrage || e.Data.Asset != "USDT" {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if len(e.Data.PaymentMethods) != 1 || e.Data.PaymentMethods[0] != "Revolut" {
+		t.Errorf("expected common arbitrage methods, got %v", e.Data.PaymentMethods)
+	}
+	if NewWebhookEvent("evt_2", Notification{}).Type != models.TrackerKindOutbid {
+		t.Error("expected outbid event for notification without kind")
+	}
+}
+
+func TestMessageEventID(t *testing.T) {
+	body, err := EncodeNotification(Notification{Exchange: "okx", Currency: "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := DecodeEnvelope(body)
+	if id := MessageEventID(body); id != e.ID {
+		t.Errorf("expected envelope id %s, got %s", e.ID, id)
+	}
+	legacy := []byte(`{"exchange":"okx","currency":"EUR"}`)
+	if id := MessageEventID(legacy); id == "" || id != MessageEventID(legacy) {
+		t.Errorf("expected stable id of legacy message, got %s", id)
+	}
+}
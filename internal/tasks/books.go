@@ -0,0 +1,45 @@
+package tasks
+
+import (
+	"p2pbot/internal/db/models"
+	"p2pbot/internal/services"
+	"sync"
+)
+
+// bookCache keeps advertisement books fetched during one observer tick,
+// so every book is requested from exchange only once
+type bookCache struct {
+	mu    sync.Mutex
+	books map[models.BookKey]*bookEntry
+	// onFetch is called once for every successfully fetched book
+	onFetch func(key models.BookKey, ads []services.P2PItemI)
+}
+
+type bookEntry struct {
+	once sync.Once
+	ads  []services.P2PItemI
+	err  error
+}
+
+func newBookCache(onFetch func(key models.BookKey, ads []services.P2PItemI)) *bookCache {
+	return &bookCache{books: make(map[models.BookKey]*bookEntry), onFetch: onFetch}
+}
+
+// Get returns advertisements for key, fetching them from ex on first call
+func (c *bookCache) Get(key models.BookKey, ex services.ExchangeI) ([]services.P2PItemI, error) {
+	c.mu.Lock()
+	entry, ok := c.books[key]
+	if !ok {
+		entry = &bookEntry{}
+		c.books[key] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.ads, entry.err = ex.GetAds(key.Asset, key.Currency, key.Side)
+		if entry.err == nil && c.onFetch != nil {
+			c.onFetch(key, entry.ads)
+		}
+	})
+	return entry.ads, entry.err
+}
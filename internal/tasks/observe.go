@@ -10,10 +10,12 @@ import (
 	"p2pbot/internal/rediscl"
 	"p2pbot/internal/services"
 	"p2pbot/internal/utils"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -21,82 +23,157 @@ type AdsObserver struct {
 	trackerService       *services.TrackerService
 	subscriptionsService *services.SubscriptionService
 	userService          *services.UserService
+	marketService        *services.MarketService
+	arbitrageService     *services.ArbitrageService
+	notificationService  *services.NotificationService
+	settingsService      *services.SettingsService
 	exchanges            []services.ExchangeI
 	rabbitCl             *rabbitmq.RabbitMQ
+	scheduler            *Scheduler
 }
 
 func NewAdsObserver(
 	trackerService *services.TrackerService,
 	userService *services.UserService,
 	subscriptionsService *services.SubscriptionService,
+	marketService *services.MarketService,
+	arbitrageService *services.ArbitrageService,
+	notificationService *services.NotificationService,
+	settingsService *services.SettingsService,
 	exchanges []services.ExchangeI,
-	rabbit *rabbitmq.RabbitMQ) *AdsObserver {
+	rabbit *rabbitmq.RabbitMQ,
+	scheduler *Scheduler) *AdsObserver {
 	return &AdsObserver{
 		trackerService:       trackerService,
 		userService:          userService,
 		subscriptionsService: subscriptionsService,
+		marketService:        marketService,
+		arbitrageService:     arbitrageService,
+		notificationService:  notificationService,
+		settingsService:      settingsService,
 		exchanges:            exchanges,
 		rabbitCl:             rabbit,
+		scheduler:            scheduler,
 	}
 }
 
-func (ao *AdsObserver) Start(rate time.Duration, ctx context.Context) {
-	if err := ao.rabbitCl.DeclareExchange("notifications"); err != nil {
+// Start checks trackers due on every scheduler tick until ctx is done
+func (ao *AdsObserver) Start(ctx context.Context) {
+	if err := ao.rabbitCl.DeclareTopicExchange(services.NotifyExchange); err != nil {
 		log.Error().Fields(map[string]interface{}{
 			"error": err.Error(),
 		}).Msg("Error declaring exchange")
 	}
-	ao.rabbitCl.Publish([]byte("Starting ads observer"))
-	// Check ads with given rate
-	ao.CheckAds()
-	ticker := time.NewTicker(rate)
+	if err := ao.rabbitCl.DeclareTopicExchange(services.EventsExchange); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error declaring exchange")
+	}
+	ao.publishSystem(services.SystemObserverStarted)
+	// Check due trackers with scheduler rate
+	ao.CheckAds(time.Now())
+	ticker := time.NewTicker(ao.scheduler.Tick())
+	defer ticker.Stop()
 	for {
 		select {
-		case <-ticker.C:
-			ao.CheckAds()
+		case now := <-ticker.C:
+			ao.CheckAds(now)
+			ao.SendQuietSummaries(now)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (ao *AdsObserver) CheckAds() {
+// CheckAds checks trackers which are due at now according to scheduler
+func (ao *AdsObserver) CheckAds(now time.Time) {
+	schedules, err := ao.trackerService.GetSchedules()
+	if err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error getting tracker schedules")
+		return
+	}
+	// Split map of exchange+asset+currency+side -> [trackerID] by exchange
+	idsByExchange := make(map[string]map[models.BookKey][]int)
+	for key, ids := range ao.scheduler.Due(now, schedules) {
+		if idsByExchange[key.Exchange] == nil {
+			idsByExchange[key.Exchange] = make(map[models.BookKey][]int)
+		}
+		idsByExchange[key.Exchange][key] = ids
+	}
+
+	// Books fetched during this tick, shared between exchanges for spread alerts
+	books := newBookCache(ao.OnBookFetched)
 	var wg sync.WaitGroup
 	for _, ex := range ao.exchanges {
+		idsMap := idsByExchange[strings.ToLower(ex.GetName())]
+		if len(idsMap) == 0 {
+			continue
+		}
+		log.Debug().Fields(map[string]any{
+			"map": idsMap,
+		}).Msg("monitoring ads")
 
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			// Get map of "currency+side" -> [trackerID]
-			idsMap, err := ao.trackerService.GetIdsByCurrency(strings.ToLower(ex.GetName()))
-			log.Debug().Fields(map[string]any{
-				"map": idsMap,
-			}).Msg("monitoring ads")
-			if err != nil {
-				return
-			}
-			ao.CheckAdsOnExchange(ex, idsMap)
+			ao.CheckAdsOnExchange(ex, idsMap, books)
 		}()
 	}
 	wg.Wait()
+	if ao.scheduler.ArbitragesDue(now) {
+		ao.CheckArbitrages(books)
+	}
+}
+
+// OnBookFetched stores market snapshot and publishes live order book of fetched book
+func (ao *AdsObserver) OnBookFetched(key models.BookKey, ads []services.P2PItemI) {
+	ao.SaveSnapshot(key, ads)
+	ao.PublishBook(key, ads)
+}
+
+// PublishBook sends top of the book to dashboard order book feeds
+func (ao *AdsObserver) PublishBook(key models.BookKey, ads []services.P2PItemI) {
+	body, err := json.Marshal(services.NewBook(key, ads))
+	if err != nil {
+		log.Error().Msg("Error converting book to json")
+		return
+	}
+	if err := ao.rabbitCl.PublishTo(services.EventsExchange, services.BookEventsKey(key), body); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+			"book":  key,
+		}).Msg("Error publishing book")
+	}
+}
+
+// SaveSnapshot stores market history of fetched book
+func (ao *AdsObserver) SaveSnapshot(key models.BookKey, ads []services.P2PItemI) {
+	if len(ads) == 0 {
+		return
+	}
+	if err := ao.marketService.SaveSnapshot(key, ads); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+			"book":  key,
+		}).Msg("Error saving market snapshot")
+	}
 }
 
-func (ao *AdsObserver) CheckAdsOnExchange(ex services.ExchangeI, idsMap map[string][]int) {
+func (ao *AdsObserver) CheckAdsOnExchange(ex services.ExchangeI, idsMap map[models.BookKey][]int, books *bookCache) {
 	log.Info().Msg("Checking ads on " + ex.GetName())
 	var wg sync.WaitGroup
 	for key, ids := range idsMap {
 		wg.Add(1)
 		go func() error {
 			defer wg.Done()
-			// key, for example: "CZKSELL"
-			currency := key[:3]
-			side := key[3:]
-			ads, err := ex.GetAds(currency, side)
+			ads, err := books.Get(key, ex)
 			if err != nil {
 				return err
 			}
 			for _, id := range ids {
-				ao.CheckTracker(ads, id)
+				ao.CheckTracker(books, ads, id)
 			}
 			return nil
 		}()
@@ -105,21 +182,108 @@ func (ao *AdsObserver) CheckAdsOnExchange(ex services.ExchangeI, idsMap map[stri
 	log.Info().Msg("Finished checking ads on " + ex.GetName())
 }
 
-func (ao *AdsObserver) CheckTracker(ads []services.P2PItemI, trackerID int) {
+// ConsumeCommands declares commands exchange on r and handles commands sent by bot
+func (ao *AdsObserver) ConsumeCommands(r *rabbitmq.RabbitMQ) error {
+	if err := r.DeclareExchange("commands"); err != nil {
+		return err
+	}
+	if err := r.DeclareQueue("commands", rabbitmq.QueueCommands); err != nil {
+		return err
+	}
+	return r.StartConsuming(rabbitmq.QueueCommands, ao.HandleCommand)
+}
+
+// HandleCommand runs command sent by bot, failed commands are not retried
+// because user can repeat them
+func (ao *AdsObserver) HandleCommand(msg amqp.Delivery) error {
+	var cmd services.Command
+	if err := json.Unmarshal(msg.Body, &cmd); err != nil {
+		return fmt.Errorf("%w: invalid command: %v", rabbitmq.ErrPoison, err)
+	}
+	switch cmd.Kind {
+	case services.CommandCheckTracker:
+		if err := ao.CheckTrackerNow(int(cmd.TrackerID)); err != nil {
+			log.Error().Fields(map[string]interface{}{
+				"error": err.Error(),
+				"id":    cmd.TrackerID,
+			}).Msg("Error checking tracker")
+		}
+	default:
+		return fmt.Errorf("%w: unknown command %s", rabbitmq.ErrPoison, cmd.Kind)
+	}
+	return nil
+}
+
+// CheckTrackerNow fetches tracker book and checks tracker outside of schedule
+func (ao *AdsObserver) CheckTrackerNow(trackerID int) error {
+	tracker, err := ao.trackerService.GetTrackerById(trackerID)
+	if err != nil {
+		return err
+	}
+	ex := ao.getExchange(tracker.Exchange)
+	if ex == nil {
+		return fmt.Errorf("exchange %s not enabled", tracker.Exchange)
+	}
+	books := newBookCache(ao.OnBookFetched)
+	ads, err := books.Get(models.BookKey{
+		Exchange: tracker.Exchange,
+		Asset:    tracker.Asset,
+		Currency: tracker.Currency,
+		Side:     tracker.Side,
+	}, ex)
+	if err != nil {
+		return err
+	}
+	ao.CheckTracker(books, ads, trackerID)
+	return nil
+}
+
+func (ao *AdsObserver) CheckTracker(books *bookCache, ads []services.P2PItemI, trackerID int) {
 	tracker, err := ao.trackerService.GetTrackerById(trackerID)
 	if err != nil {
 		return
 	}
+	switch tracker.Kind {
+	case models.TrackerKindPrice:
+		ao.CheckPriceAlert(tracker, ads)
+	case models.TrackerKindSpread:
+		ao.CheckSpreadAlert(books, tracker, ads)
+	default:
+		ao.CheckOutbid(tracker, ads)
+	}
+}
+
+// CheckOutbid notifies user if his advertisement is not the best one.
+// Competitor advertisements are filtered by nickname lists and compared by tracker outbid rules
+func (ao *AdsObserver) CheckOutbid(tracker *models.Tracker, ads []services.P2PItemI) {
+	var err error
+	// Team accounts are friendly on every tracker of user
+	friendly := append(slices.Clone(tracker.IgnoreNicknames), tracker.TeamNicknames...)
 	if tracker.IsAggregated {
 		for _, ad := range ads {
 			if utils.ComparePaymentMethods(ad.GetPaymentMethods(), tracker.Payment) {
 				// if advertisements payment methods contain one of the tracker payment methods
 				if ad.GetName() != tracker.Username && ad.GetPrice() != tracker.Price {
 					// if advertisement name doesnt match tracker username
+					if !services.NicknameCounts(ad.GetName(), friendly, tracker.OnlyNicknames) ||
+						!services.CompetitorCounts(tracker.OutbidRules, ad) {
+						// Friendly, small advertisements and new advertisers are ignored
+						continue
+					}
+					if !services.ExceedsGap(tracker.OutbidRules, tracker.Price, ad.GetPrice()) {
+						// Competitor price is within tolerated gap
+						ao.publishStateEvents(tracker, "", tracker.WaitingUpdate, false, 0, nil)
+						tracker.WaitingUpdate = false
+						if err := ao.trackerService.CreateTracker(tracker); err != nil {
+							log.Printf("Error updating tracker waiting update: %s", err)
+						}
+						return
+					}
 					// Notify user
 					if !tracker.WaitingUpdate {
 						ao.Notify(tracker, ad)
 					}
+					ao.publishStateEvents(tracker, "", tracker.WaitingUpdate, true, 0, ad)
 					tracker.WaitingUpdate = true
 					if err := ao.trackerService.CreateTracker(tracker); err != nil {
 						log.Printf("Error updating tracker waiting update: %s", err)
@@ -128,6 +292,7 @@ func (ao *AdsObserver) CheckTracker(ads []services.P2PItemI, trackerID int) {
 				} else {
 					// Tracked advertisement is the best advertisement across payment methods
 					// Set outbidded to false
+					ao.publishStateEvents(tracker, "", tracker.WaitingUpdate, false, ad.GetPrice(), nil)
 					tracker.WaitingUpdate = false
 					tracker.Price = ad.GetPrice()
 					log.Printf("User %s is not outbidded on %s", tracker.Username, tracker.Exchange)
@@ -142,11 +307,27 @@ func (ao *AdsObserver) CheckTracker(ads []services.P2PItemI, trackerID int) {
 		for _, pMethod := range tracker.Payment {
 			for _, ad := range ads {
 				if utils.Contains(ad.GetPaymentMethods(), pMethod.Id) {
-					if ad.GetName() != tracker.Username && ad.GetPrice() != tracker.Price {
+					competitor := ad.GetName() != tracker.Username && ad.GetPrice() != tracker.Price
+					if competitor && (!services.NicknameCounts(ad.GetName(), friendly, tracker.OnlyNicknames) ||
+						!services.CompetitorCounts(tracker.OutbidRules, ad)) {
+						// Friendly, small advertisements and new advertisers are ignored
+						continue
+					}
+					if competitor && !services.ExceedsGap(tracker.OutbidRules, tracker.Price, ad.GetPrice()) {
+						// Competitor price is within tolerated gap
+						ao.publishStateEvents(tracker, pMethod.Id, pMethod.Outbided, false, 0, nil)
+						err = ao.trackerService.UpdateMethodOutbiddded(tracker.ID, pMethod.Id, false)
+						if err != nil {
+							log.Printf("Error updating outbidded status for %s on %s", pMethod.Id, tracker.Exchange)
+						}
+						break
+					}
+					if competitor {
 						//Notify user
 						if !pMethod.Outbided {
 							ao.Notify(tracker, ad)
 						}
+						ao.publishStateEvents(tracker, pMethod.Id, pMethod.Outbided, true, 0, ad)
 						//Set outbidded to true
 						err = ao.trackerService.UpdateMethodOutbiddded(tracker.ID, pMethod.Id, true)
 						if err != nil {
@@ -154,6 +335,7 @@ func (ao *AdsObserver) CheckTracker(ads []services.P2PItemI, trackerID int) {
 						}
 					} else {
 						//set outbidded to false
+						ao.publishStateEvents(tracker, pMethod.Id, pMethod.Outbided, false, ad.GetPrice(), nil)
 						err := ao.trackerService.UpdateMethodOutbiddded(tracker.ID, pMethod.Id, false)
 						if err != nil {
 							log.Printf("Error updating outbidded status for %s on %s", pMethod.Id, tracker.Exchange)
@@ -176,32 +358,301 @@ func (ao *AdsObserver) CheckTracker(ads []services.P2PItemI, trackerID int) {
 	}
 }
 
-func (ao *AdsObserver) Notify(tracker *models.Tracker, ad services.P2PItemI) {
-	user, err := ao.userService.GetUserByID(tracker.UserID)
+/*
+publishStateEvents sends tracker state transitions to user's dashboard.
+price is new price of tracked advertisement(0 if unknown),
+competitor is advertisement which outbidded tracker
+*/
+func (ao *AdsObserver) publishStateEvents(tracker *models.Tracker, method string, wasOutbid, outbid bool,
+	price float64, competitor services.P2PItemI) {
+	for _, e := range services.TrackerStateEvents(tracker.ID, wasOutbid, outbid, tracker.Price, price) {
+		e.PaymentMethod = method
+		if competitor != nil && e.Type == services.EventOutbid {
+			e.Competitor = competitor.GetName()
+			e.CompetitorPrice = competitor.GetPrice()
+		}
+		ao.publishEvent(tracker.UserID, e)
+	}
+}
+
+// publishSystem sends system message about observer to notify.system topic
+func (ao *AdsObserver) publishSystem(event string) {
+	body, err := services.NewEnvelope(services.MessageSystem, services.SystemEvent{
+		Service: "observer",
+		Event:   event,
+	})
 	if err != nil {
-		log.Error().Msg("Error retreiving user")
+		log.Error().Msg("Error converting system message to json")
+		return
+	}
+	if err := ao.rabbitCl.PublishTopic(services.NotifyExchange, services.SystemKey(event), body); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error publishing system message")
+	}
+}
+
+// publishEvent sends event to user's events queue
+func (ao *AdsObserver) publishEvent(userID int, e services.UserEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Error().Msg("Error converting event to json")
+		return
+	}
+	if err := ao.rabbitCl.PublishTo(services.EventsExchange, services.UserEventsKey(userID), body); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error publishing event")
+	}
+}
+
+// bestAd returns first advertisement which accepts one of tracker payment methods,
+// any advertisement matches if tracker has no payment methods
+func bestAd(tracker *models.Tracker, ads []services.P2PItemI) services.P2PItemI {
+	for _, ad := range ads {
+		if len(tracker.Payment) == 0 || utils.ComparePaymentMethods(ad.GetPaymentMethods(), tracker.Payment) {
+			return ad
+		}
+	}
+	return nil
+}
+
+// updateAlert notifies user once when alert condition becomes true,
+// alert is rearmed when condition is false again
+func (ao *AdsObserver) updateAlert(tracker *models.Tracker, triggered bool, price float64, n services.Notification) {
+	if triggered && !tracker.WaitingUpdate {
+		ao.SendNotification(tracker, n)
+	}
+	tracker.WaitingUpdate = triggered
+	tracker.Price = price
+	if err := ao.trackerService.CreateTracker(tracker); err != nil {
+		log.Printf("Error updating alert tracker: %s", err)
+	}
+}
+
+// CheckPriceAlert notifies user when best price goes below/above threshold
+func (ao *AdsObserver) CheckPriceAlert(tracker *models.Tracker, ads []services.P2PItemI) {
+	ad := bestAd(tracker, ads)
+	if ad == nil {
+		return
+	}
+	price := ad.GetPrice()
+	triggered := (tracker.Direction == "below" && price < tracker.Threshold) ||
+		(tracker.Direction == "above" && price > tracker.Threshold)
+
+	ao.updateAlert(tracker, triggered, price, services.Notification{Data: ad})
+}
+
+/*
+CheckSpreadAlert notifies user when spread between best prices on tracker exchange
+and compare exchange is above threshold(%).
+Payment methods filter only tracker exchange, because ids differ between exchanges
+*/
+func (ao *AdsObserver) CheckSpreadAlert(books *bookCache, tracker *models.Tracker, ads []services.P2PItemI) {
+	ad := bestAd(tracker, ads)
+	if ad == nil {
 		return
 	}
-	if user.ChatID == nil {
-		// if telegram  not connected
-		log.Info().Msg(fmt.Sprintf("Can't sent notification, because user with userID %d has no telegram connected", user.ID))
+	compareEx := ao.getExchange(tracker.CompareExchange)
+	if compareEx == nil {
+		log.Error().Str("exchange", tracker.CompareExchange).Msg("Compare exchange not enabled")
+		return
+	}
+	compareAds, err := books.Get(models.BookKey{
+		Exchange: tracker.CompareExchange,
+		Asset:    tracker.Asset,
+		Currency: tracker.Currency,
+		Side:     tracker.Side,
+	}, compareEx)
+	if err != nil || len(compareAds) == 0 {
 		return
 	}
+
+	price := ad.GetPrice()
+	comparePrice := compareAds[0].GetPrice()
+	spread := services.Spread(price, comparePrice)
+
+	ao.updateAlert(tracker, spread > tracker.Threshold, price, services.Notification{
+		Data:            ad,
+		CompareExchange: tracker.CompareExchange,
+		ComparePrice:    comparePrice,
+		Spread:          spread,
+	})
+}
+
+func (ao *AdsObserver) getExchange(name string) services.ExchangeI {
+	for _, ex := range ao.exchanges {
+		if strings.ToLower(ex.GetName()) == name {
+			return ex
+		}
+	}
+	return nil
+}
+
+// CheckArbitrages checks all arbitrages, books already fetched during this tick are reused
+func (ao *AdsObserver) CheckArbitrages(books *bookCache) {
+	arbitrages, err := ao.arbitrageService.GetAll()
+	if err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error getting arbitrages")
+		return
+	}
+	var wg sync.WaitGroup
+	for _, arb := range arbitrages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ao.CheckArbitrage(books, arb)
+		}()
+	}
+	wg.Wait()
+}
+
+/*
+CheckArbitrage notifies user when net spread between buying on buy exchange
+and selling on sell exchange is above threshold(%).
+Asset is bought from SELL advertisements on buy exchange
+and sold to BUY advertisements on sell exchange
+*/
+func (ao *AdsObserver) CheckArbitrage(books *bookCache, arb *models.Arbitrage) {
+	buyEx := ao.getExchange(arb.BuyExchange)
+	sellEx := ao.getExchange(arb.SellExchange)
+	if buyEx == nil || sellEx == nil {
+		log.Error().Int64("arbitrage", arb.ID).Msg("Arbitrage exchange not enabled")
+		return
+	}
+	buyAds, err := books.Get(models.BookKey{
+		Exchange: arb.BuyExchange,
+		Asset:    arb.Asset,
+		Currency: arb.Currency,
+		Side:     "SELL",
+	}, buyEx)
+	if err != nil {
+		return
+	}
+	sellAds, err := books.Get(models.BookKey{
+		Exchange: arb.SellExchange,
+		Asset:    arb.Asset,
+		Currency: arb.Currency,
+		Side:     "BUY",
+	}, sellEx)
+	if err != nil {
+		return
+	}
+	// Payment method ids differ between exchanges, so they are matched by names
+	buyMethods, err := buyEx.GetCachedPaymentMethods(arb.Currency)
+	if err != nil {
+		log.Error().Str("exchange", arb.BuyExchange).Msg("Error getting payment methods")
+		return
+	}
+	sellMethods, err := sellEx.GetCachedPaymentMethods(arb.Currency)
+	if err != nil {
+		log.Error().Str("exchange", arb.SellExchange).Msg("Error getting payment methods")
+		return
+	}
+
+	opportunity := services.FindArbitrage(buyAds, sellAds,
+		services.PaymentMethodNames(buyMethods),
+		services.PaymentMethodNames(sellMethods),
+		arb.Payment,
+		ao.arbitrageService.Fees(arb.BuyExchange, arb.SellExchange))
+	if opportunity == nil {
+		return
+	}
+
+	triggered := opportunity.Spread > arb.Threshold
+	if triggered && !arb.WaitingUpdate && arb.Notify {
+		ao.publishNotification(arb.UserID, services.Notification{
+			Data:            opportunity.BuyAd,
+			Exchange:        arb.BuyExchange,
+			Asset:           arb.Asset,
+			Currency:        arb.Currency,
+			Kind:            services.NotificationKindArbitrage,
+			Threshold:       arb.Threshold,
+			CompareExchange: arb.SellExchange,
+			ComparePrice:    opportunity.SellAd.GetPrice(),
+			CompareName:     opportunity.SellAd.GetName(),
+			Spread:          opportunity.Spread,
+			Methods:         opportunity.Methods,
+		})
+	}
+	arb.WaitingUpdate = triggered
+	arb.LastSpread = opportunity.Spread
+	if err := ao.arbitrageService.Save(arb); err != nil {
+		log.Printf("Error updating arbitrage: %s", err)
+	}
+}
+
+func (ao *AdsObserver) Notify(tracker *models.Tracker, ad services.P2PItemI) {
+	ao.SendNotification(tracker, services.Notification{Data: ad})
+}
+
+// SendNotification fills notification with tracker data and publishes it
+func (ao *AdsObserver) SendNotification(tracker *models.Tracker, n services.Notification) {
 	// Check if notifications enabled
 	if !tracker.Notify {
 		return
 	}
-	// Create notification
-	n := services.Notification{
-		Data:     ad,
-		Exchange: tracker.Exchange,
-		Side:     tracker.Side,
-		Currency: tracker.Currency,
-		ChatID:   *user.ChatID,
+	// Check if tracker muted for a while from notification keyboard
+	if muted, err := ao.trackerService.IsMuted(tracker.ID); err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error checking tracker mute")
+	} else if muted {
+		return
+	}
+	// Fill notification
+	n.Kind = tracker.Kind
+	n.Threshold = tracker.Threshold
+	n.Direction = tracker.Direction
+	n.Exchange = tracker.Exchange
+	n.Asset = tracker.Asset
+	n.Side = tracker.Side
+	n.Currency = tracker.Currency
+	n.TrackerID = tracker.ID
+	n.PreviousPrice = tracker.Price
+	ao.publishNotification(tracker.UserID, n)
+}
+
+// paymentMethods returns ids and names of payment methods of notification advertisement,
+// id is used as name of methods exchange doesn't know
+func (ao *AdsObserver) paymentMethods(n services.Notification) []services.PaymentMethod {
+	if n.Data == nil {
+		return nil
 	}
-	nJson, err := json.Marshal(n)
+	var known []services.PaymentMethod
+	if ex := ao.getExchange(strings.ToLower(n.Exchange)); ex != nil {
+		methods, err := ex.GetCachedPaymentMethods(n.Currency)
+		if err != nil {
+			log.Error().Fields(map[string]interface{}{
+				"error":    err.Error(),
+				"exchange": n.Exchange,
+			}).Msg("Error getting payment methods")
+		}
+		known = methods
+	}
+	out := make([]services.PaymentMethod, 0)
+	for _, id := range n.Data.GetPaymentMethods() {
+		name, err := services.GetPMethodName(known, id)
+		if err != nil {
+			name = id
+		}
+		out = append(out, services.PaymentMethod{Id: id, Name: name})
+	}
+	return out
+}
+
+// publishNotification sends notification to user's telegram,
+// users without active subscription receive limited number of notifications
+func (ao *AdsObserver) publishNotification(userID int, n services.Notification) {
+	user, err := ao.userService.GetUserByID(userID)
 	if err != nil {
-		log.Error().Msg("Error converting user to json")
+		log.Error().Msg("Error retreiving user")
+		return
+	}
+	n.UserID = user.ID
+	// Users without telegram receive notifications in linked channels only
+	if user.ChatID != nil {
+		n.ChatID = *user.ChatID
 	}
 	// Check if user has active subscription, if not allow only 3 notifications a week
 	subscription, err := ao.subscriptionsService.GetByUserId(user.ID)
@@ -209,8 +660,10 @@ func (ao *AdsObserver) Notify(tracker *models.Tracker, ad services.P2PItemI) {
 		log.Error().Str("error ", err.Error()).Msg("Error getting subscription")
 		return
 	}
-	if subscription == nil || subscription.ValidUntil.Before(time.Now()) {
-		ctx := rediscl.RDB.Ctx
+	ctx := rediscl.RDB.Ctx
+	limited := false
+	free := subscription == nil || subscription.ValidUntil.Before(time.Now())
+	if free {
 		count := rediscl.RDB.Client.Get(ctx, fmt.Sprintf("notification:%d", user.ID))
 		if count.Err() == redis.Nil {
 			rediscl.RDB.Client.Set(ctx, fmt.Sprintf("notification:%d", user.ID), 1, time.Hour*24*7)
@@ -220,23 +673,148 @@ func (ao *AdsObserver) Notify(tracker *models.Tracker, ad services.P2PItemI) {
 				log.Error().Msg("Error getting notification count")
 				return
 			}
-			if c > 3 {
-				log.Info().Msg(fmt.Sprintf("User %d has reached notification limit", user.ID))
-				return
-			}
+			limited = c > 3
 		}
-		if err := ao.rabbitCl.Publish([]byte(nJson)); err != nil {
-			log.Error().Fields(map[string]interface{}{
-				"error": err.Error(),
-			}).Msg("Error publishing message")
+	}
+
+	settings, err := ao.settingsService.Get(user.ID)
+	if err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error getting user settings")
+		settings = services.DefaultSettings(user.ID)
+	}
+	n.Channels = settings.Channels
+	if !services.AllowsChannel(settings.Channels, models.ChannelTelegram) {
+		n.ChatID = 0
+	}
+	now := time.Now()
+	hourKey := fmt.Sprintf("notification:hour:%d", user.ID)
+
+	// Every notification is stored in delivery log, even not published ones
+	status := models.NotificationPending
+	switch {
+	case limited:
+		status = models.NotificationLimited
+	case n.Kind == services.NotificationKindSummary:
+		// summary is sent once quiet hours end regardless of hourly limit
+	case services.InQuietHours(settings, now):
+		status = models.NotificationQueued
+	case settings.MaxPerHour > 0:
+		count, err := rediscl.RDB.Client.Get(ctx, hourKey).Int()
+		if err != nil && err != redis.Nil {
+			log.Error().Str("error", err.Error()).Msg("Error getting hourly notification count")
+		}
+		if count >= settings.MaxPerHour {
+			status = models.NotificationThrottled
 		}
+	}
+	if err := ao.notificationService.Log(user.ID, &n, status); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error saving notification")
+	}
+	switch status {
+	case models.NotificationLimited:
+		log.Info().Msg(fmt.Sprintf("User %d has reached notification limit", user.ID))
+		return
+	case models.NotificationThrottled:
+		log.Info().Msg(fmt.Sprintf("User %d has reached hourly notification limit", user.ID))
+		return
+	case models.NotificationQueued:
+		ao.queueQuietNotification(user.ID, services.SummaryLine(n, now.In(services.Location(settings))))
+		return
+	}
+
+	n.PaymentMethods = ao.paymentMethods(n)
+	body, err := services.EncodeNotification(n)
+	if err != nil {
+		log.Error().Msg("Error converting notification to json")
+		return
+	}
+	if err := ao.rabbitCl.PublishTopic(services.NotifyExchange, services.NotificationKey(n), body); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error": err.Error(),
+		}).Msg("Error publishing message")
+		if n.ID != 0 {
+			if err := ao.notificationService.MarkFailed(n.ID, err); err != nil {
+				log.Error().Fields(map[string]interface{}{
+					"error": err.Error(),
+					"id":    n.ID,
+				}).Msg("Error updating notification status")
+			}
+		}
+		return
+	}
+	if free {
 		rediscl.RDB.Client.Incr(ctx, fmt.Sprintf("notification:%d", user.ID))
-	} else {
-		// Just publish notification if user has active subscription
-		if err := ao.rabbitCl.Publish([]byte(nJson)); err != nil {
-			log.Error().Fields(map[string]interface{}{
-				"error": err.Error(),
-			}).Msg("Error publishing message")
+	}
+	if settings.MaxPerHour > 0 {
+		if count, err := rediscl.RDB.Client.Incr(ctx, hourKey).Result(); err == nil && count == 1 {
+			rediscl.RDB.Client.Expire(ctx, hourKey, time.Hour)
+		}
+	}
+	ao.publishEvent(user.ID, services.NotificationUserEvent(n))
+}
+
+// quietUsers is redis set of users with notifications held during quiet hours
+const quietUsers = "quiet:users"
+
+func quietKey(userID int) string {
+	return fmt.Sprintf("quiet:queue:%d", userID)
+}
+
+// queueQuietNotification holds summary line of notification until user's quiet hours end
+func (ao *AdsObserver) queueQuietNotification(userID int, line string) {
+	ctx := rediscl.RDB.Ctx
+	pipe := rediscl.RDB.Client.TxPipeline()
+	pipe.RPush(ctx, quietKey(userID), line)
+	pipe.SAdd(ctx, quietUsers, userID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Error().Fields(map[string]interface{}{
+			"error":   err.Error(),
+			"user_id": userID,
+		}).Msg("Error queueing notification for quiet hours")
+	}
+}
+
+// SendQuietSummaries sends summary of held notifications to users whose quiet hours ended
+func (ao *AdsObserver) SendQuietSummaries(now time.Time) {
+	ctx := rediscl.RDB.Ctx
+	ids, err := rediscl.RDB.Client.SMembers(ctx, quietUsers).Result()
+	if err != nil {
+		log.Error().Str("error", err.Error()).Msg("Error getting users with quiet hours")
+		return
+	}
+	for _, idStr := range ids {
+		var userID int
+		if _, err := fmt.Sscan(idStr, &userID); err != nil {
+			continue
+		}
+		settings, err := ao.settingsService.Get(userID)
+		if err != nil {
+			log.Error().Str("error", err.Error()).Msg("Error getting user settings")
+			continue
+		}
+		if services.InQuietHours(settings, now) {
+			continue
+		}
+		// take queue atomically, notifications queued later start new summary
+		var lines *redis.StringSliceCmd
+		_, err = rediscl.RDB.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			lines = pipe.LRange(ctx, quietKey(userID), 0, -1)
+			pipe.Del(ctx, quietKey(userID))
+			pipe.SRem(ctx, quietUsers, idStr)
+			return nil
+		})
+		if err != nil {
+			log.Error().Str("error", err.Error()).Msg("Error taking quiet hours queue")
+			continue
+		}
+		if len(lines.Val()) == 0 {
+			continue
 		}
+		ao.publishNotification(userID, services.Notification{
+			Kind:    services.NotificationKindSummary,
+			Summary: lines.Val(),
+		})
 	}
 }
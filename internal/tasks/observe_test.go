@@ -56,16 +56,25 @@ func TestMain(m *testing.M) {
 		panic(err)
 	}
 
+	exs, err := services.NewExchanges(cfg)
+	if err != nil {
+		panic(err)
+	}
+
 	trackerRepo := repository.NewTrackerRepository(DB)
 	userRepo := repository.NewUserRepository(DB)
-	trackerService := services.NewTrackerService(trackerRepo)
+	trackerService := services.NewTrackerService(trackerRepo, exs)
 	userService := services.NewUserService(userRepo)
 	subscriptionRepo := repository.NewSubscriptionRepository(DB)
 	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
-
-	bybit := services.NewBybitExcahnge(cfg)
-	binance := services.NewBinanceExchange(cfg)
-	exs := []services.ExchangeI{binance, bybit}
+	marketRepo := repository.NewMarketRepository(DB)
+	marketService := services.NewMarketService(marketRepo)
+	arbitrageRepo := repository.NewArbitrageRepository(DB)
+	arbitrageService := services.NewArbitrageService(arbitrageRepo, exs, cfg)
+	notificationRepo := repository.NewNotificationRepository(DB)
+	notificationService := services.NewNotificationService(notificationRepo)
+	settingsRepo := repository.NewSettingsRepository(DB)
+	settingsService := services.NewSettingsService(settingsRepo)
 
 	rabbit, err := rabbitmq.NewRabbitMQ(cfg)
 	if err != nil {
@@ -74,7 +83,8 @@ func TestMain(m *testing.M) {
 
 	rediscl.InitRedisClient(cfg.Redis.Host, cfg.Redis.Port)
 
-	observer = NewAdsObserver(trackerService, userService, subscriptionService, exs, rabbit)
+	observer = NewAdsObserver(trackerService, userService, subscriptionService, marketService, arbitrageService, notificationService, settingsService, exs.List(), rabbit,
+		NewScheduler(cfg))
 
 	m.Run()
 }
@@ -0,0 +1,107 @@
+package tasks
+
+import (
+	"p2pbot/internal/config"
+	"p2pbot/internal/db/models"
+	"time"
+)
+
+// Default scheduler intervals, used when observer config is empty
+const (
+	defaultTick         = 5 * time.Second
+	defaultFreeInterval = time.Minute
+	defaultPaidInterval = 10 * time.Second
+)
+
+/*
+Scheduler decides which trackers are checked on observer tick.
+Trackers are bucketed by polling interval, every bucket is checked
+when its interval has passed since the previous check of this bucket.
+Users without subscription can't poll faster than free interval,
+subscribers can't poll faster than paid interval.
+Scheduler is used only from observer loop and is not safe for concurrent use
+*/
+type Scheduler struct {
+	tick         time.Duration
+	freeInterval time.Duration
+	paidInterval time.Duration
+	lastRun      map[time.Duration]time.Time
+	// arbitrages are checked with free interval
+	lastArbitrages time.Time
+}
+
+func NewScheduler(cfg *config.Config) *Scheduler {
+	s := &Scheduler{
+		tick:         time.Duration(cfg.Observer.Tick) * time.Second,
+		freeInterval: time.Duration(cfg.Observer.FreeInterval) * time.Second,
+		paidInterval: time.Duration(cfg.Observer.PaidInterval) * time.Second,
+		lastRun:      make(map[time.Duration]time.Time),
+	}
+	if s.tick <= 0 {
+		s.tick = defaultTick
+	}
+	if s.freeInterval <= 0 {
+		s.freeInterval = defaultFreeInterval
+	}
+	if s.paidInterval <= 0 {
+		s.paidInterval = defaultPaidInterval
+	}
+	return s
+}
+
+// Tick returns how often observer asks scheduler for due trackers
+func (s *Scheduler) Tick() time.Duration {
+	return s.tick
+}
+
+// Interval returns polling interval of tracker limited by subscription tier
+func (s *Scheduler) Interval(schedule models.TrackerSchedule) time.Duration {
+	minimum := s.freeInterval
+	if schedule.Paid {
+		minimum = s.paidInterval
+	}
+	interval := time.Duration(schedule.Interval) * time.Second
+	if interval < minimum {
+		return minimum
+	}
+	return interval
+}
+
+// due reports if interval has passed since last,
+// half of tick is tolerated because ticker fires a bit earlier or later
+func (s *Scheduler) due(last time.Time, interval time.Duration, now time.Time) bool {
+	return last.IsZero() || now.Add(s.tick/2).Sub(last) >= interval
+}
+
+// Due returns ids of trackers which should be checked at now,
+// grouped by exchange+asset+currency+side so every book is fetched once
+func (s *Scheduler) Due(now time.Time, schedules []models.TrackerSchedule) map[models.BookKey][]int {
+	buckets := make(map[time.Duration]bool)
+	out := make(map[models.BookKey][]int)
+	for _, schedule := range schedules {
+		interval := s.Interval(schedule)
+		isDue, ok := buckets[interval]
+		if !ok {
+			isDue = s.due(s.lastRun[interval], interval, now)
+			buckets[interval] = isDue
+		}
+		if isDue {
+			out[schedule.BookKey] = append(out[schedule.BookKey], schedule.ID)
+		}
+	}
+	for interval, isDue := range buckets {
+		if isDue {
+			s.lastRun[interval] = now
+		}
+	}
+	return out
+}
+
+// ArbitragesDue reports if arbitrages should be checked at now
+func (s *Scheduler) ArbitragesDue(now time.Time) bool {
+	if !s.due(s.lastArbitrages, s.freeInterval, now) {
+		return false
+	}
+	s.lastArbitrages = now
+	return true
+}
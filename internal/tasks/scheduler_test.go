@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"p2pbot/internal/config"
+	"p2pbot/internal/db/models"
+	"testing"
+	"time"
+)
+
+func newTestScheduler() *Scheduler {
+	cfg := &config.Config{}
+	cfg.Observer.Tick = 5
+	cfg.Observer.FreeInterval = 60
+	cfg.Observer.PaidInterval = 10
+	return NewScheduler(cfg)
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	s := newTestScheduler()
+
+	tests := []struct {
+		schedule models.TrackerSchedule
+		want     time.Duration
+	}{
+		{models.TrackerSchedule{Interval: 0}, time.Minute},
+		{models.TrackerSchedule{Interval: 15}, time.Minute},
+		{models.TrackerSchedule{Interval: 300}, 5 * time.Minute},
+		{models.TrackerSchedule{Interval: 0, Paid: true}, 10 * time.Second},
+		{models.TrackerSchedule{Interval: 15, Paid: true}, 15 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.Interval(tt.schedule); got != tt.want {
+			t.Errorf("Interval(%+v) = %v, want %v", tt.schedule, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerDue(t *testing.T) {
+	s := newTestScheduler()
+	book := models.BookKey{Exchange: "binance", Asset: "USDT", Currency: "EUR", Side: "BUY"}
+	schedules := []models.TrackerSchedule{
+		{ID: 1, BookKey: book},
+		{ID: 2, BookKey: book, Paid: true},
+		{ID: 3, BookKey: models.BookKey{Exchange: "bybit", Asset: "USDT", Currency: "EUR", Side: "BUY"}, Paid: true},
+	}
+
+	start := time.Now()
+	due := s.Due(start, schedules)
+	if len(due[book]) != 2 || len(due) != 2 {
+		t.Fatalf("expected all trackers on first tick, got %v", due)
+	}
+
+	// Ticker fires slightly earlier than 10 seconds later
+	due = s.Due(start.Add(5*time.Second), schedules)
+	if len(due) != 0 {
+		t.Errorf("expected no trackers after 5s, got %v", due)
+	}
+	due = s.Due(start.Add(9900*time.Millisecond), schedules)
+	if len(due[book]) != 1 || due[book][0] != 2 || len(due) != 2 {
+		t.Errorf("expected only paid trackers after 10s, got %v", due)
+	}
+
+	due = s.Due(start.Add(time.Minute), schedules)
+	if len(due[book]) != 2 {
+		t.Errorf("expected all trackers after a minute, got %v", due)
+	}
+}
+
+func TestSchedulerArbitragesDue(t *testing.T) {
+	s := newTestScheduler()
+	start := time.Now()
+	if !s.ArbitragesDue(start) {
+		t.Error("expected arbitrages on first tick")
+	}
+	if s.ArbitragesDue(start.Add(30 * time.Second)) {
+		t.Error("expected no arbitrages after 30s")
+	}
+	if !s.ArbitragesDue(start.Add(time.Minute)) {
+		t.Error("expected arbitrages after a minute")
+	}
+}
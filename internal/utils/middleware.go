@@ -28,7 +28,37 @@ func (c CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
+// TokenQueryParam is query parameter with access token for requests without Authorization header
+const TokenQueryParam = "access_token"
+
+// redactQuery hides access token in logged query
+func redactQuery(q url.Values) string {
+	if q.Has(TokenQueryParam) {
+		q.Set(TokenQueryParam, "redacted")
+	}
+	return q.Encode()
+}
+
+// CheckJWT validates access token of Authorization header
 func CheckJWT(next echo.HandlerFunc) echo.HandlerFunc {
+	return checkJWT(jwtmiddleware.AuthHeaderTokenExtractor)(next)
+}
+
+/*
+CheckStreamJWT validates access token of Authorization header or access_token
+query parameter, browsers can't set headers of websocket and event stream requests.
+
+Tokens in urls leak to proxy logs and browser history,
+so it is used only for GET routes of live streams
+*/
+func CheckStreamJWT(next echo.HandlerFunc) echo.HandlerFunc {
+	return checkJWT(jwtmiddleware.MultiTokenExtractor(
+		jwtmiddleware.AuthHeaderTokenExtractor,
+		jwtmiddleware.ParameterTokenExtractor(TokenQueryParam),
+	))(next)
+}
+
+func checkJWT(extractor jwtmiddleware.TokenExtractor) echo.MiddlewareFunc {
 	issuerURL, err := url.Parse("https://" + os.Getenv("AUTH0_DOMAIN") + "/")
 	if err != nil {
 		log.Fatal().Err(err).Msg("Error parsing issuer URL")
@@ -62,26 +92,29 @@ func CheckJWT(next echo.HandlerFunc) echo.HandlerFunc {
 	middleware := jwtmiddleware.New(
 		jwtValidator.ValidateToken,
 		jwtmiddleware.WithErrorHandler(errorHandler),
+		jwtmiddleware.WithTokenExtractor(extractor),
 	)
 
-	return func(ctx echo.Context) error {
-		encounteredError := true
-		var handler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
-			encounteredError = false
-			ctx.SetRequest(r)
-			next(ctx)
-		}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			encounteredError := true
+			var handler http.HandlerFunc = func(w http.ResponseWriter, r *http.Request) {
+				encounteredError = false
+				ctx.SetRequest(r)
+				next(ctx)
+			}
 
-		middleware.CheckJWT(handler).ServeHTTP(ctx.Response(), ctx.Request())
+			middleware.CheckJWT(handler).ServeHTTP(ctx.Response(), ctx.Request())
 
-		if encounteredError {
-			ctx.JSON(
-				http.StatusUnauthorized,
-				map[string]string{"message": "JWT is invalid."},
-			)
-		}
+			if encounteredError {
+				ctx.JSON(
+					http.StatusUnauthorized,
+					map[string]string{"message": "JWT is invalid."},
+				)
+			}
 
-		return nil
+			return nil
+		}
 	}
 }
 
@@ -92,7 +125,7 @@ func LoggingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 			"method":     c.Request().Method,
 			"uri":        c.Request().URL.Path,
 			"user_agent": c.Request().UserAgent(),
-			"query":      c.Request().URL.RawQuery,
+			"query":      redactQuery(c.Request().URL.Query()),
 			"client_ip":  c.RealIP(),
 		}).Msg("Request")
 